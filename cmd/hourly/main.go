@@ -1,21 +1,34 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/spencer-p/surfdash/pkg/meta"
 	"github.com/spencer-p/surfdash/pkg/noaa"
 	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+	"github.com/spencer-p/surfdash/pkg/visualize"
 )
 
 func main() {
-	dur := 14 * 24 * time.Hour
+	raw := flag.Bool("raw", false, "print the tide every two hours instead of a chart")
+	width := flag.Int("width", visualize.DefaultTerminalWidth, "width of the chart in characters")
+	days := flag.Int("days", 14, "number of days to show")
+	flag.Parse()
+
+	dur := time.Duration(*days) * 24 * time.Hour
 	step := 2 * time.Hour
 
+	model := splines.ModelFor(noaa.SantaCruz)
 	query := noaa.PredictionQuery{
 		Start:    time.Now(),
 		Duration: dur,
 		Station:  noaa.SantaCruz,
+		Interval: model.Interval(),
+		Location: sunset.SantaCruz.Location,
 	}
 
 	preds, err := noaa.GetPredictions(&query)
@@ -26,8 +39,23 @@ func main() {
 
 	tstart := time.Time(preds[0].Time)
 	tend := tstart.Add(dur)
-	spl := splines.CurvesBetween(preds)
-	for t := tstart; t.Before(tend); t = t.Add(step) {
-		fmt.Printf("%f ", spl.Eval(t))
+	spl := model.Interpolate(preds)
+	if *raw {
+		for t := tstart; t.Before(tend); t = t.Add(step) {
+			fmt.Printf("%f ", spl.Eval(t))
+		}
+		return
+	}
+
+	if len(spl) == 0 {
+		fmt.Println("not enough predictions to draw")
+		return
+	}
+	sunevents := sunset.GetSunEvents(tstart, dur, sunset.SantaCruz)
+	chart := visualize.NewTerminal(spl, sunevents, tstart, spl[len(spl)-1].End)
+	chart.SetSize(*width, visualize.DefaultTerminalRows)
+	chart.SetGoodTimes(meta.GoodTimes2(meta.Conditions{Tides: preds, SunEvents: sunevents, Model: model}, meta.Options{}))
+	if _, err := chart.Encode(os.Stdout); err != nil {
+		fmt.Printf("failed to draw chart: %v\n", err)
 	}
 }
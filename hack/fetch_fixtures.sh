@@ -0,0 +1,31 @@
+#!/usr/bin/env bash
+
+# Downloads tide predictions from NOAA for the interpolation model tests.
+
+set -o pipefail -o errexit
+
+STATION=${STATION:-9413745}
+OUT=${OUT:-"$(dirname "$0")/../pkg/noaa/splines/testdata"}
+API="https://api.tidesandcurrents.noaa.gov/api/prod/datagetter"
+# Times are in GMT, as surfdash asks for them.
+COMMON="station=${STATION}&product=predictions&datum=MLLW&time_zone=gmt&units=english&format=json"
+
+# fetch downloads a URL to a file. NOAA reports errors with a 200 and an
+# "error" object, so check that predictions came back.
+fetch() {
+	local tmp
+	tmp=$(mktemp)
+	curl -sSf "$1" > "${tmp}"
+	if ! grep -q '"predictions"' "${tmp}"; then
+		echo "no predictions from $1:" >&2
+		cat "${tmp}" >&2
+		rm -f "${tmp}"
+		exit 1
+	fi
+	mv "${tmp}" "$2"
+}
+
+# The high/low predictions start a day early and end a day late so that the
+# models are defined over the whole six minute range.
+fetch "${API}?${COMMON}&interval=hilo&begin_date=20231231&end_date=20240104" "${OUT}/santacruz_hilo.json"
+fetch "${API}?${COMMON}&interval=6&begin_date=20240101&end_date=20240103" "${OUT}/santacruz_6min.json"
@@ -4,9 +4,11 @@ import (
 	"embed"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/spencer-p/surfdash/pkg/data"
 	"github.com/spencer-p/surfdash/pkg/handlers"
 	"github.com/spencer-p/surfdash/pkg/metrics"
 
@@ -32,11 +34,23 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	// The database is optional. Without one, preferences live in cookies.
+	var store data.Store
+	if os.Getenv("PGHOST") == "" {
+		log.Printf("PGHOST is not set, keeping preferences in cookies only")
+	} else {
+		pg, err := data.PostgresFromEnv()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		store = pg
+	}
+
 	r := mux.NewRouter().StrictSlash(true)
 	r.Use(helpttp.WithLog)
 	r.Use(metrics.LatencyHandler)
 	s := r.PathPrefix(env.Prefix).Subrouter()
-	handlers.Register(s, env.RedirectPrefix, staticContent)
+	handlers.NewServer(store).Register(s, env.RedirectPrefix, staticContent)
 
 	if env.Prefix != "/" {
 		r.Handle("/", http.RedirectHandler(env.Prefix, http.StatusFound))
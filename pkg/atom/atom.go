@@ -0,0 +1,65 @@
+package atom
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const namespace = "http://www.w3.org/2005/Atom"
+
+// Feed is an Atom feed.
+type Feed struct {
+	XMLName xml.Name  `xml:"feed"`
+	NS      string    `xml:"xmlns,attr"`
+	ID      string    `xml:"id"`
+	Title   string    `xml:"title"`
+	Updated time.Time `xml:"updated"`
+	Author  *Person   `xml:"author,omitempty"`
+	Links   []Link    `xml:"link"`
+	Entries []Entry   `xml:"entry"`
+}
+
+// Person is the author of a feed.
+type Person struct {
+	Name string `xml:"name"`
+}
+
+// Link is a reference from a feed or entry to a web resource.
+type Link struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// Entry is an item of a feed. Readers recognize entries by ID and show them
+// again when Updated changes.
+type Entry struct {
+	ID      string    `xml:"id"`
+	Title   string    `xml:"title"`
+	Updated time.Time `xml:"updated"`
+	Summary string    `xml:"summary,omitempty"`
+	Links   []Link    `xml:"link"`
+}
+
+// Encode writes the feed. If the feed has no Updated time, it is the latest
+// time an entry was updated.
+func (f *Feed) Encode(w io.Writer) error {
+	out := *f
+	out.NS = namespace
+	for _, e := range out.Entries {
+		if e.Updated.After(out.Updated) {
+			out.Updated = e.Updated
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,57 @@
+package atom
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncode(t *testing.T) {
+	older := time.Date(2021, time.April, 2, 12, 0, 0, 0, time.UTC)
+	newer := time.Date(2021, time.April, 3, 12, 0, 0, 0, time.UTC)
+	feed := Feed{
+		ID:    "urn:example:feed",
+		Title: "Good times",
+		Links: []Link{{Rel: "self", Href: "https://example.com/feed.atom"}},
+		Entries: []Entry{{
+			ID:      "urn:example:1",
+			Title:   "Sat 04/03 <early>",
+			Updated: older,
+		}, {
+			ID:      "urn:example:2",
+			Title:   "Sun 04/04",
+			Updated: newer,
+			Summary: "tide is low",
+			Links:   []Link{{Rel: "enclosure", Type: "image/svg+xml", Href: "https://example.com/chart"}},
+		}},
+	}
+	var b bytes.Buffer
+	if err := feed.Encode(&b); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:example:feed</id>
+	<title>Good times</title>
+	<updated>2021-04-03T12:00:00Z</updated>
+	<link rel="self" href="https://example.com/feed.atom"></link>
+	<entry>
+		<id>urn:example:1</id>
+		<title>Sat 04/03 &lt;early&gt;</title>
+		<updated>2021-04-02T12:00:00Z</updated>
+	</entry>
+	<entry>
+		<id>urn:example:2</id>
+		<title>Sun 04/04</title>
+		<updated>2021-04-03T12:00:00Z</updated>
+		<summary>tide is low</summary>
+		<link rel="enclosure" type="image/svg+xml" href="https://example.com/chart"></link>
+	</entry>
+</feed>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("Encode() (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,2 @@
+// Package atom writes feeds in the Atom format of RFC 4287.
+package atom
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -16,9 +17,66 @@ type User struct {
 	MaxTide  *float64
 	LastSeen time.Time
 	Birthday time.Time
+	// Availability is the JSON encoding of when the user can surf.
+	Availability string
+	// MinDuration is the shortest good time the user wants to see.
+	MinDuration time.Duration
+	// MergeGap is the longest break to merge good times across.
+	MergeGap time.Duration
+	// Direction is the way the user wants the tide to move, or empty for
+	// any way.
+	Direction string
+	// MaxRate is the fastest the user wants the tide to change, in feet per
+	// hour.
+	MaxRate *float64
+	// Weekdays are the days of the week the user surfs, like "sat,sun".
+	// Empty is every day.
+	Weekdays string
+	// FeedToken is the secret part of the user's feed URLs, which work
+	// without a session.
+	FeedToken string `gorm:"index"`
 }
 
-func PostgresFromEnvOrDie() *gorm.DB {
+// FeedEntry remembers when an entry of a feed last changed.
+type FeedEntry struct {
+	// Key identifies the entry among the entries of every feed.
+	Key string `gorm:"primaryKey"`
+	// Version describes the content of the entry. It changes when the
+	// entry does.
+	Version string
+	Updated time.Time
+	// Sequence counts the changes to the entry.
+	Sequence int
+}
+
+// ErrNotFound is the error of a Store without the user or feed entry.
+var ErrNotFound = errors.New("not found")
+
+// Store keeps users.
+type Store interface {
+	// User finds the user with the ID, or returns ErrNotFound.
+	User(id uint) (*User, error)
+	// UserByFeedToken finds the user with the feed token, or returns
+	// ErrNotFound.
+	UserByFeedToken(token string) (*User, error)
+	// SaveUser creates or updates the user. New users are given an ID.
+	SaveUser(user *User) error
+	// FeedEntry finds the feed entry with the key, or returns ErrNotFound.
+	FeedEntry(key string) (*FeedEntry, error)
+	// SaveFeedEntry creates or updates the feed entry.
+	SaveFeedEntry(entry *FeedEntry) error
+}
+
+// Postgres is a Store in a Postgres database.
+type Postgres struct {
+	db *gorm.DB
+}
+
+var _ Store = &Postgres{}
+
+// PostgresFromEnv connects to the database described by the PGHOST, PGPORT,
+// and PGPASSWORD environment variables.
+func PostgresFromEnv() (*Postgres, error) {
 	pw := os.Getenv("PGPASSWORD")
 	host := os.Getenv("PGHOST")
 	port := os.Getenv("PGPORT")
@@ -28,8 +86,50 @@ func PostgresFromEnvOrDie() *gorm.DB {
 		port)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		panic("failed to connect to database")
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.AutoMigrate(&User{}, &FeedEntry{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate tables: %w", err)
+	}
+	return &Postgres{db: db}, nil
+}
+
+func (p *Postgres) User(id uint) (*User, error) {
+	var user User
+	if tx := p.db.First(&user, id); tx.Error != nil {
+		return nil, notFound(tx.Error)
+	}
+	return &user, nil
+}
+
+func (p *Postgres) UserByFeedToken(token string) (*User, error) {
+	var user User
+	if tx := p.db.Where("feed_token = ?", token).First(&user); tx.Error != nil {
+		return nil, notFound(tx.Error)
+	}
+	return &user, nil
+}
+
+func (p *Postgres) SaveUser(user *User) error {
+	return p.db.Save(user).Error
+}
+
+func (p *Postgres) FeedEntry(key string) (*FeedEntry, error) {
+	var entry FeedEntry
+	if tx := p.db.Where("key = ?", key).First(&entry); tx.Error != nil {
+		return nil, notFound(tx.Error)
+	}
+	return &entry, nil
+}
+
+func (p *Postgres) SaveFeedEntry(entry *FeedEntry) error {
+	return p.db.Save(entry).Error
+}
+
+// notFound replaces the error gorm uses for missing records with ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
 	}
-	db.AutoMigrate(&User{})
-	return db
+	return err
 }
@@ -0,0 +1,551 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// maxRange is the longest time the v3 API will look at in one request.
+const maxRange = 28 * day
+
+// spot is a place to surf.
+type spot struct {
+	Name    string
+	Station noaa.Station
+	Place   sunset.Place
+}
+
+// spots are the places surfdash knows about. The first is the default.
+var spots = []spot{{
+	Name:    "Santa Cruz",
+	Station: noaa.SantaCruz,
+	Place:   sunset.SantaCruz,
+}}
+
+var defaultSpot = spots[0]
+
+// findSpot finds the spot with the station ID in s, or the default spot if s
+// is empty.
+func findSpot(s string) (spot, error) {
+	if s == "" {
+		return defaultSpot, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return spot{}, fmt.Errorf("spot %q is not a station ID", s)
+	}
+	for _, sp := range spots {
+		if sp.Station == noaa.Station(id) {
+			return sp, nil
+		}
+	}
+	return spot{}, fmt.Errorf("unknown spot %d", id)
+}
+
+// apiError is the body of every error from the v3 API.
+type apiError struct {
+	Error apiErrorDetail `json:"error"`
+}
+
+type apiErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiSpot struct {
+	// Name is empty for stations that are not spots.
+	Name    string       `json:"name,omitempty"`
+	Station noaa.Station `json:"station"`
+}
+
+type apiGoodTime struct {
+	Start           time.Time      `json:"start"`
+	End             time.Time      `json:"end"`
+	DurationSeconds int64          `json:"duration_seconds"`
+	Direction       meta.Direction `json:"direction,omitempty"`
+	Score           int            `json:"score"`
+	Reasons         []meta.Reason  `json:"reasons"`
+}
+
+type apiGoodTimes struct {
+	Spot      apiSpot       `json:"spot"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	GoodTimes []apiGoodTime `json:"good_times"`
+}
+
+func newAPISpot(sp spot) apiSpot {
+	return apiSpot{Name: sp.Name, Station: sp.Station}
+}
+
+func newAPIGoodTime(gt meta.GoodTime) apiGoodTime {
+	start := gt.Time.Truncate(time.Second)
+	end := gt.Time.Add(gt.Duration).Truncate(time.Second)
+	reasons := gt.Reasons
+	if reasons == nil {
+		reasons = []meta.Reason{}
+	}
+	return apiGoodTime{
+		Start:           start,
+		End:             end,
+		DurationSeconds: int64(end.Sub(start) / time.Second),
+		Direction:       gt.Direction,
+		Score:           gt.Score,
+		Reasons:         reasons,
+	}
+}
+
+// writeJSON writes v as the response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode JSON result: %+v", err)
+	}
+}
+
+// writeAPIError writes an error from the v3 API.
+func writeAPIError(w http.ResponseWriter, code int, err error) {
+	if code >= http.StatusInternalServerError {
+		log.Printf("API error: %+v", err)
+	}
+	writeJSON(w, code, apiError{Error: apiErrorDetail{Code: code, Message: err.Error()}})
+}
+
+// apiRange reads the start, end, and days parameters. Start defaults to now,
+// and end defaults to a week after start. Only one of end and days may be
+// given.
+func (srv *Server) apiRange(r *http.Request) (start, end time.Time, err error) {
+	start = srv.Clock.Now()
+	if s := r.FormValue("start"); s != "" {
+		if start, err = time.Parse(time.RFC3339, s); err != nil {
+			return start, end, fmt.Errorf("start %q is not an RFC 3339 time", s)
+		}
+	}
+	end = start.Add(forecastLength)
+	if s := r.FormValue("days"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days <= 0 {
+			return start, end, fmt.Errorf("days %q is not a positive integer", s)
+		}
+		end = start.Add(time.Duration(days) * day)
+	}
+	if s := r.FormValue("end"); s != "" {
+		if r.FormValue("days") != "" {
+			return start, end, errors.New("only one of end and days may be given")
+		}
+		if end, err = time.Parse(time.RFC3339, s); err != nil {
+			return start, end, fmt.Errorf("end %q is not an RFC 3339 time", s)
+		}
+	}
+	if !end.After(start) {
+		return start, end, errors.New("end is not after start")
+	}
+	if end.Sub(start) > maxRange {
+		return start, end, fmt.Errorf("range is longer than %d days", int(maxRange/day))
+	}
+	return start, end, nil
+}
+
+// apiOptions reads the user's preferences from the token parameter, if any,
+// and overrides them with the min_tide, max_tide, max_rate, direction, and
+// weekdays parameters.
+func (srv *Server) apiOptions(r *http.Request) (meta.Options, error) {
+	opts := meta.Options{}
+	user, err := srv.feedUser(r)
+	if err != nil {
+		return opts, err
+	}
+	if user != nil {
+		opts = optionsForUser(user)
+	}
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"min_tide", &opts.LowTideThresh},
+		{"max_tide", &opts.HighTideThresh},
+		{"max_rate", &opts.MaxRate},
+	} {
+		s := r.FormValue(p.name)
+		if s == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return opts, fmt.Errorf("%s %q is not a number", p.name, s)
+		}
+		*p.dst = &f
+	}
+	if opts.MaxRate != nil && *opts.MaxRate <= 0 {
+		return opts, fmt.Errorf("max_rate %v is not positive", *opts.MaxRate)
+	}
+	if s := r.FormValue("direction"); s != "" {
+		dir, err := meta.ParseDirection(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Direction = &dir
+	}
+	if s := r.FormValue("weekdays"); s != "" {
+		days, err := parseWeekdays(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Days = days
+	}
+	return opts, nil
+}
+
+// serveGoodTimes3 serves good times as JSON. See static/openapi.json for its
+// parameters.
+func (srv *Server) serveGoodTimes3(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	sp, err := findSpot(r.FormValue("spot"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	start, end, err := srv.apiRange(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := srv.apiOptions(r)
+	if errors.Is(err, errUnknownFeed) {
+		writeAPIError(w, http.StatusNotFound, errors.New("unknown token"))
+		return
+	} else if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	goodTimes, _, err := srv.fetchGoodTimes2(sp, start, end.Sub(start), opts)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	goodTimes, err = rankGoodTimes(r, goodTimes)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result := apiGoodTimes{
+		Spot:      newAPISpot(sp),
+		Start:     start.Truncate(time.Second),
+		End:       end.Truncate(time.Second),
+		GoodTimes: []apiGoodTime{},
+	}
+	for _, gt := range goodTimes {
+		result.GoodTimes = append(result.GoodTimes, newAPIGoodTime(gt))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// makeOpenAPIHandler serves the description of the v3 API.
+func makeOpenAPIHandler(content embed.FS) http.HandlerFunc {
+	filename := "static/openapi.json"
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := content.ReadFile(filename)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, fmt.Errorf("failed to read %q: %w", filename, err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// serveAPINotFound answers unknown v3 paths with a JSON error.
+func serveAPINotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+}
+
+// maxSamples is the most tide heights /api/v3/tides will sample.
+const maxSamples = 10000
+
+// anySpot is like findSpot, but it accepts any station ID. The place of
+// unknown stations has no Location.
+func anySpot(s string) (spot, error) {
+	sp, err := findSpot(s)
+	if err == nil {
+		return sp, nil
+	}
+	id, convErr := strconv.Atoi(s)
+	if convErr != nil || id <= 0 {
+		return spot{}, fmt.Errorf("spot %q is not a station ID", s)
+	}
+	return spot{Station: noaa.Station(id)}, nil
+}
+
+type apiTideEvent struct {
+	Time   time.Time `json:"time"`
+	Height float64   `json:"height_ft"`
+	// Type is "high" or "low".
+	Type string `json:"type"`
+}
+
+type apiTideSample struct {
+	Time   time.Time `json:"time"`
+	Height float64   `json:"height_ft"`
+}
+
+type apiTides struct {
+	Spot    apiSpot         `json:"spot"`
+	Start   time.Time       `json:"start"`
+	End     time.Time       `json:"end"`
+	Events  []apiTideEvent  `json:"events"`
+	Samples []apiTideSample `json:"samples,omitempty"`
+}
+
+type apiSunEvent struct {
+	Time time.Time `json:"time"`
+	// Event is "sunrise" or "sunset".
+	Event string `json:"event"`
+}
+
+type apiSun struct {
+	Spot   apiSpot       `json:"spot"`
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Events []apiSunEvent `json:"events"`
+}
+
+func tideType(t noaa.Tide) string {
+	if t == noaa.LowTide {
+		return "low"
+	}
+	return "high"
+}
+
+func sunEventName(e sunset.Event) string {
+	if e == sunset.Sunrise {
+		return "sunrise"
+	}
+	return "sunset"
+}
+
+// apiFormat reads the format parameter, which is "json" (the default) or
+// "csv".
+func apiFormat(r *http.Request) (string, error) {
+	switch format := r.FormValue("format"); format {
+	case "", "json":
+		return "json", nil
+	case "csv":
+		return "csv", nil
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeCSV writes the records as the response.
+func writeCSV(w http.ResponseWriter, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		log.Printf("Failed to encode CSV result: %+v", err)
+	}
+}
+
+// serveTides3 serves the high and low tides of a station, and optionally its
+// height sampled every resolution minutes. See static/openapi.json for its
+// parameters.
+func (srv *Server) serveTides3(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	sp, err := anySpot(r.FormValue("spot"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	start, end, err := srv.apiRange(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	format, err := apiFormat(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	var resolution time.Duration
+	if s := r.FormValue("resolution"); s != "" {
+		minutes, err := strconv.Atoi(s)
+		if err != nil || minutes <= 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("resolution %q is not a positive number of minutes", s))
+			return
+		}
+		resolution = time.Duration(minutes) * time.Minute
+		if end.Sub(start)/resolution > maxSamples {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("more than %d samples; use a coarser resolution", maxSamples))
+			return
+		}
+	}
+
+	// Pad by a day on each side so the tide is continuous at the edges.
+	query := noaa.PredictionQuery{
+		Start:    start.Add(-day),
+		Duration: end.Sub(start) + 2*day,
+		Station:  sp.Station,
+		Location: sp.Place.Location,
+	}
+	hilo, err := srv.Tides.GetPredictions(&query)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch from NOAA: %w", err))
+		return
+	}
+	result := apiTides{
+		Spot:   newAPISpot(sp),
+		Start:  start.Truncate(time.Second),
+		End:    end.Truncate(time.Second),
+		Events: []apiTideEvent{},
+	}
+	for _, p := range hilo.Between(start, end) {
+		result.Events = append(result.Events, apiTideEvent{
+			Time:   p.T(),
+			Height: float64(p.Height),
+			Type:   tideType(p.Type),
+		})
+	}
+	if resolution > 0 {
+		model := splines.ModelFor(sp.Station)
+		modelPreds, err := srv.predictionsFor(model, query, hilo)
+		if err != nil {
+			writeAPIError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch from NOAA: %w", err))
+			return
+		}
+		spl := model.Interpolate(modelPreds)
+		for t := start.Truncate(time.Second); t.Before(end); t = t.Add(resolution) {
+			h := spl.Eval(t)
+			if math.IsNaN(h) {
+				continue
+			}
+			result.Samples = append(result.Samples, apiTideSample{Time: t, Height: h})
+		}
+	}
+
+	if format == "csv" {
+		records := [][]string{{"time", "height_ft", "type"}}
+		events, samples := result.Events, result.Samples
+		// Merge the events and samples in order of time.
+		for len(events) > 0 || len(samples) > 0 {
+			if len(samples) == 0 || (len(events) > 0 && events[0].Time.Before(samples[0].Time)) {
+				e := events[0]
+				records = append(records, []string{e.Time.Format(time.RFC3339), formatFeet(e.Height), e.Type})
+				events = events[1:]
+			} else {
+				s := samples[0]
+				records = append(records, []string{s.Time.Format(time.RFC3339), formatFeet(s.Height), ""})
+				samples = samples[1:]
+			}
+		}
+		writeCSV(w, records)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func formatFeet(h float64) string {
+	return strconv.FormatFloat(h, 'f', 3, 64)
+}
+
+// serveSun3 serves the sunrises and sunsets at a spot, or at the lat and lon
+// parameters in the time zone tz. See static/openapi.json for its parameters.
+func (srv *Server) serveSun3(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	sp, err := anySpot(r.FormValue("spot"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	if lat, lon := r.FormValue("lat"), r.FormValue("lon"); lat != "" || lon != "" {
+		place, err := placeFromForm(lat, lon, r.FormValue("tz"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		sp.Place = place
+	}
+	if sp.Place.Location == nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("location of station %d is unknown; pass lat and lon", sp.Station))
+		return
+	}
+	start, end, err := srv.apiRange(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	format, err := apiFormat(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Start a day early, since the events start with the sunrise on the day
+	// of start.
+	events := sunset.GetSunEvents(start.Add(-day), end.Sub(start)+2*day, sp.Place)
+	result := apiSun{
+		Spot:   newAPISpot(sp),
+		Start:  start.Truncate(time.Second),
+		End:    end.Truncate(time.Second),
+		Events: []apiSunEvent{},
+	}
+	for _, ev := range events.Between(start, end) {
+		result.Events = append(result.Events, apiSunEvent{
+			Time:  ev.Time.Truncate(time.Second),
+			Event: sunEventName(ev.Event),
+		})
+	}
+
+	if format == "csv" {
+		records := [][]string{{"time", "event"}}
+		for _, ev := range result.Events {
+			records = append(records, []string{ev.Time.Format(time.RFC3339), ev.Event})
+		}
+		writeCSV(w, records)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// placeFromForm reads a place from latitude and longitude in degrees and an
+// IANA time zone, which defaults to UTC.
+func placeFromForm(lat, lon, tz string) (sunset.Place, error) {
+	var place sunset.Place
+	var err error
+	if place.Lat, err = strconv.ParseFloat(lat, 64); err != nil || place.Lat < -90 || place.Lat > 90 {
+		return place, fmt.Errorf("lat %q is not a latitude", lat)
+	}
+	if place.Long, err = strconv.ParseFloat(lon, 64); err != nil || place.Long < -180 || place.Long > 180 {
+		return place, fmt.Errorf("lon %q is not a longitude", lon)
+	}
+	place.Location = time.UTC
+	if tz != "" {
+		if place.Location, err = time.LoadLocation(tz); err != nil {
+			return place, fmt.Errorf("unknown time zone %q", tz)
+		}
+	}
+	return place, nil
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAPIRange(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC)
+	srv := testServer(now)
+	for _, tc := range []struct {
+		name      string
+		query     string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{{
+		name:      "defaults",
+		query:     "",
+		wantStart: now,
+		wantEnd:   now.Add(7 * day),
+	}, {
+		name:      "start",
+		query:     "start=2021-06-03T00:00:00Z",
+		wantStart: time.Date(2021, time.June, 3, 0, 0, 0, 0, time.UTC),
+		wantEnd:   time.Date(2021, time.June, 10, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:      "days",
+		query:     "days=2",
+		wantStart: now,
+		wantEnd:   now.Add(2 * day),
+	}, {
+		name:      "end",
+		query:     "end=2021-06-02T00:00:00Z",
+		wantStart: now,
+		wantEnd:   time.Date(2021, time.June, 2, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:      "the longest range",
+		query:     "days=28",
+		wantStart: now,
+		wantEnd:   now.Add(28 * day),
+	}, {
+		name:    "longer than 28 days",
+		query:   "days=29",
+		wantErr: true,
+	}, {
+		name:    "end longer than 28 days",
+		query:   "end=2021-06-29T06:00:01Z",
+		wantErr: true,
+	}, {
+		name:    "days and end",
+		query:   "days=2&end=2021-06-02T00:00:00Z",
+		wantErr: true,
+	}, {
+		name:    "zero days",
+		query:   "days=0",
+		wantErr: true,
+	}, {
+		name:    "days not a number",
+		query:   "days=two",
+		wantErr: true,
+	}, {
+		name:    "end before start",
+		query:   "end=2021-05-31T00:00:00Z",
+		wantErr: true,
+	}, {
+		name:    "end at start",
+		query:   "start=2021-06-01T00:00:00Z&end=2021-06-01T00:00:00Z",
+		wantErr: true,
+	}, {
+		name:    "start not RFC 3339",
+		query:   "start=2021-06-01",
+		wantErr: true,
+	}, {
+		name:    "end not RFC 3339",
+		query:   "end=tomorrow",
+		wantErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := srv.apiRange(httptest.NewRequest(http.MethodGet, "/api/v3/goodtimes?"+tc.query, nil))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("apiRange = %v, %v; wanted an error", start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apiRange failed: %v", err)
+			}
+			if !start.Equal(tc.wantStart) || !end.Equal(tc.wantEnd) {
+				t.Errorf("apiRange = %v, %v; wanted %v, %v", start, end, tc.wantStart, tc.wantEnd)
+			}
+		})
+	}
+}
+
+func TestServeGoodTimes3Shape(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location))
+	w := httptest.NewRecorder()
+	srv.serveGoodTimes3(w, httptest.NewRequest(http.MethodGet, "/api/v3/goodtimes?days=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type %q, wanted application/json", got)
+	}
+
+	var got struct {
+		Spot      map[string]any   `json:"spot"`
+		Start     string           `json:"start"`
+		End       string           `json:"end"`
+		GoodTimes []map[string]any `json:"good_times"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode good times: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"name": "Santa Cruz", "station": float64(defaultSpot.Station)}, got.Spot); diff != "" {
+		t.Errorf("spot (-want,+got): %s", diff)
+	}
+	if got.Start != "2021-06-01T06:00:00-07:00" || got.End != "2021-06-02T06:00:00-07:00" {
+		t.Errorf("got range %s to %s, wanted a day from the clock", got.Start, got.End)
+	}
+	if len(got.GoodTimes) == 0 {
+		t.Fatalf("got no good times")
+	}
+	for _, gt := range got.GoodTimes {
+		var keys []string
+		for k := range gt {
+			keys = append(keys, k)
+		}
+		want := []string{"direction", "duration_seconds", "end", "reasons", "score", "start"}
+		if diff := cmp.Diff(want, keys, cmpSorted); diff != "" {
+			t.Errorf("good time fields (-want,+got): %s", diff)
+		}
+		start, err1 := time.Parse(time.RFC3339, gt["start"].(string))
+		end, err2 := time.Parse(time.RFC3339, gt["end"].(string))
+		if err1 != nil || err2 != nil {
+			t.Fatalf("good time has times %v and %v", gt["start"], gt["end"])
+		}
+		if got, want := gt["duration_seconds"], end.Sub(start).Seconds(); got != want {
+			t.Errorf("got duration_seconds %v, wanted %v from %s to %s", got, want, start, end)
+		}
+	}
+}
+
+// cmpSorted compares slices of strings regardless of order.
+var cmpSorted = cmp.Transformer("sort", func(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+})
+
+func TestAPIErrors(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC))
+	for _, tc := range []struct {
+		name     string
+		handler  http.HandlerFunc
+		method   string
+		target   string
+		wantCode int
+		want     string
+	}{
+		{"method", srv.serveGoodTimes3, http.MethodPost, "/api/v3/goodtimes", http.StatusMethodNotAllowed, "method POST not allowed"},
+		{"unknown spot", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?spot=1612340", http.StatusNotFound, "unknown spot 1612340"},
+		{"bad spot", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?spot=abc", http.StatusNotFound, `spot "abc" is not a station ID`},
+		{"bad station", srv.serveTides3, http.MethodGet, "/api/v3/tides?spot=abc", http.StatusNotFound, `spot "abc" is not a station ID`},
+		{"negative station", srv.serveTides3, http.MethodGet, "/api/v3/tides?spot=-1", http.StatusNotFound, `spot "-1" is not a station ID`},
+		{"bad station for the sun", srv.serveSun3, http.MethodGet, "/api/v3/sun?spot=abc", http.StatusNotFound, `spot "abc" is not a station ID`},
+		{"days and end", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?days=1&end=2021-06-02T00:00:00Z", http.StatusBadRequest, "only one of end and days may be given"},
+		{"too long", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?days=29", http.StatusBadRequest, "range is longer than 28 days"},
+		{"bad tide", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?min_tide=low", http.StatusBadRequest, `min_tide "low" is not a number`},
+		{"bad sort", srv.serveGoodTimes3, http.MethodGet, "/api/v3/goodtimes?sort=height", http.StatusBadRequest, `cannot sort by "height"`},
+		{"bad format", srv.serveTides3, http.MethodGet, "/api/v3/tides?format=xml", http.StatusBadRequest, `unknown format "xml"`},
+		{"bad resolution", srv.serveTides3, http.MethodGet, "/api/v3/tides?resolution=0", http.StatusBadRequest, `resolution "0" is not a positive number of minutes`},
+		{"too many samples", srv.serveTides3, http.MethodGet, "/api/v3/tides?days=28&resolution=1", http.StatusBadRequest, "more than 10000 samples; use a coarser resolution"},
+		{"sun without a place", srv.serveSun3, http.MethodGet, "/api/v3/sun?spot=1612340", http.StatusNotFound, "location of station 1612340 is unknown; pass lat and lon"},
+		{"bad latitude", srv.serveSun3, http.MethodGet, "/api/v3/sun?lat=91&lon=0", http.StatusBadRequest, `lat "91" is not a latitude`},
+		{"bad time zone", srv.serveSun3, http.MethodGet, "/api/v3/sun?lat=21&lon=-158&tz=Mars/Olympus", http.StatusBadRequest, `unknown time zone "Mars/Olympus"`},
+		{"no endpoint", serveAPINotFound, http.MethodGet, "/api/v3/waves", http.StatusNotFound, "no such endpoint /api/v3/waves"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest(tc.method, tc.target, nil))
+			if w.Code != tc.wantCode {
+				t.Errorf("got code %d, wanted %d", w.Code, tc.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("got Content-Type %q, wanted application/json", got)
+			}
+			var got apiError
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode error: %v", err)
+			}
+			want := apiError{Error: apiErrorDetail{Code: tc.wantCode, Message: tc.want}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("error (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeTides3(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC))
+	target := "/api/v3/tides?start=2021-06-01T00:00:00Z&end=2021-06-01T12:00:00Z&resolution=180"
+
+	w := httptest.NewRecorder()
+	srv.serveTides3(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	var got apiTides
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode tides: %v", err)
+	}
+	at := func(h int) time.Time { return time.Date(2021, time.June, 1, h, 0, 0, 0, time.UTC) }
+	want := apiTides{
+		Spot:  newAPISpot(defaultSpot),
+		Start: at(0),
+		End:   at(12),
+		Events: []apiTideEvent{
+			{Time: at(0), Height: -1, Type: "low"},
+			{Time: at(6), Height: 5, Type: "high"},
+		},
+		Samples: []apiTideSample{
+			{Time: at(0), Height: -1},
+			{Time: at(3), Height: 2},
+			{Time: at(6), Height: 5},
+			{Time: at(9), Height: 2},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpInstants); diff != "" {
+		t.Errorf("tides (-want,+got): %s", diff)
+	}
+
+	w = httptest.NewRecorder()
+	srv.serveTides3(w, httptest.NewRequest(http.MethodGet, target+"&format=csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d for CSV: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("got Content-Type %q, wanted CSV", got)
+	}
+	// Samples at the time of an event come first. The range ends before the
+	// low tide at noon.
+	wantCSV := strings.Join([]string{
+		"time,height_ft,type",
+		"2021-06-01T00:00:00Z,-1.000,",
+		"2021-06-01T00:00:00Z,-1.000,low",
+		"2021-06-01T03:00:00Z,2.000,",
+		"2021-06-01T06:00:00Z,5.000,",
+		"2021-06-01T06:00:00Z,5.000,high",
+		"2021-06-01T09:00:00Z,2.000,",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(wantCSV, w.Body.String()); diff != "" {
+		t.Errorf("CSV (-want,+got): %s", diff)
+	}
+}
+
+func TestServeSun3(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC))
+	la := defaultSpot.Place.Location
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		target string
+		want   []apiSunEvent
+		// wantCSV is the body with format=csv.
+		wantCSV string
+	}{{
+		name:   "default spot",
+		target: "/api/v3/sun?start=2021-06-01T00:00:00-07:00&days=1",
+		want: []apiSunEvent{
+			{Time: time.Date(2021, time.June, 1, 5, 51, 0, 0, la), Event: "sunrise"},
+			{Time: time.Date(2021, time.June, 1, 20, 23, 0, 0, la), Event: "sunset"},
+		},
+	}, {
+		name:   "a place",
+		target: "/api/v3/sun?lat=21.3&lon=-157.86&tz=Pacific/Honolulu&start=2021-06-01T00:00:00-10:00&days=1",
+		want: []apiSunEvent{
+			{Time: time.Date(2021, time.June, 1, 5, 50, 0, 0, honolulu), Event: "sunrise"},
+			{Time: time.Date(2021, time.June, 1, 19, 11, 0, 0, honolulu), Event: "sunset"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.serveSun3(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("got code %d: %s", w.Code, w.Body)
+			}
+			var got apiSun
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode sun: %v", err)
+			}
+			// The events are to the minute.
+			for i := range got.Events {
+				got.Events[i].Time = got.Events[i].Time.Truncate(time.Minute)
+			}
+			if diff := cmp.Diff(tc.want, got.Events, cmpInstants); diff != "" {
+				t.Errorf("sun events (-want,+got): %s", diff)
+			}
+
+			w = httptest.NewRecorder()
+			srv.serveSun3(w, httptest.NewRequest(http.MethodGet, tc.target+"&format=csv", nil))
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			if len(lines) != 1+len(tc.want) || lines[0] != "time,event" {
+				t.Fatalf("CSV has lines %q, wanted a header and %d events", lines, len(tc.want))
+			}
+			for i, ev := range tc.want {
+				if !strings.HasPrefix(lines[i+1], ev.Time.Format("2006-01-02T15:04")) || !strings.HasSuffix(lines[i+1], ","+ev.Event) {
+					t.Errorf("CSV line %q, wanted %s at %s", lines[i+1], ev.Event, ev.Time.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+// cmpInstants compares times by the instant they name.
+var cmpInstants = cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })
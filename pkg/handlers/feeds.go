@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/atom"
+	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/ical"
+	"github.com/spencer-p/surfdash/pkg/meta"
+)
+
+var errUnknownFeed = errors.New("unknown feed")
+
+// feedUser finds the user whose feed token is in the token parameter. Without
+// a token, it returns nil for the public feed.
+func (srv *Server) feedUser(r *http.Request) (*data.User, error) {
+	token := r.FormValue("token")
+	if token == "" {
+		return nil, nil
+	}
+	if srv.Store == nil {
+		// Without a store, no user has a feed.
+		return nil, errUnknownFeed
+	}
+	user, err := srv.Store.UserByFeedToken(token)
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, errUnknownFeed
+	}
+	return user, err
+}
+
+// feedGoodTimes returns the good times at the spot in the spot parameter for
+// the user of the feed in the request. It writes any error to w.
+func (srv *Server) feedGoodTimes(w http.ResponseWriter, r *http.Request) (*data.User, spot, []meta.GoodTime, bool) {
+	sp, err := findSpot(r.FormValue("spot"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, err)
+		return nil, sp, nil, false
+	}
+	user, err := srv.feedUser(r)
+	if errors.Is(err, errUnknownFeed) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Unknown feed")
+		return nil, sp, nil, false
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to find feed: %+v", err)
+		log.Printf("Failed to find feed: %+v", err)
+		return nil, sp, nil, false
+	}
+	opts := meta.Options{}
+	if user != nil {
+		opts = optionsForUser(user)
+	}
+
+	goodTimes, _, err := srv.fetchGoodTimes2(sp, srv.Clock.Now(), forecastLength, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to fetch good times: %+v", err)
+		log.Printf("Failed to fetch good times: %+v", err)
+		return nil, sp, nil, false
+	}
+	return user, sp, goodTimes, true
+}
+
+// serveCalendar serves good times as an iCalendar feed. With the token
+// parameter, the feed uses that user's preferences, and with the spot
+// parameter, it is of that spot.
+func (srv *Server) serveCalendar(w http.ResponseWriter, r *http.Request) {
+	user, sp, goodTimes, ok := srv.feedGoodTimes(w, r)
+	if !ok {
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID: "-//surfdash//good times//EN",
+		Name:   feedTitle(user),
+	}
+	now := srv.Clock.Now()
+	for i, key := range goodTimeKeys(goodTimes, sp, feedName(user), feedMergeGap(user)) {
+		gt := goodTimes[i]
+		entry := srv.trackEntry(key, gt, now)
+		cal.Events = append(cal.Events, ical.Event{
+			UID:          key + "@surfdash",
+			Start:        gt.Time,
+			End:          gt.Time.Add(gt.Duration),
+			Summary:      "Good time to surf",
+			Description:  meta.JoinReasons(gt.Reasons),
+			Location:     sp.Name,
+			Sequence:     entry.Sequence,
+			LastModified: entry.Updated,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf, now); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to write calendar: %+v", err)
+		log.Printf("Failed to write calendar: %+v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// minKeyGranularity is the least that the starts of good times are rounded to
+// in their keys.
+const minKeyGranularity = 30 * time.Minute
+
+// goodTimeKeys names each good time by its spot and its start, rounded to the
+// larger of mergeGap and minKeyGranularity, so that the name survives small
+// changes to the forecast. Good times further apart than mergeGap are not
+// merged, so they round to different starts unless the gap is small. Those
+// that still share a start are told apart by their order. Good times must be
+// in order.
+func goodTimeKeys(goodTimes []meta.GoodTime, sp spot, feed string, mergeGap time.Duration) []string {
+	granularity := mergeGap
+	if granularity < minKeyGranularity {
+		granularity = minKeyGranularity
+	}
+	keys := make([]string, len(goodTimes))
+	seen := map[string]int{}
+	for i, gt := range goodTimes {
+		key := fmt.Sprintf("%d-%s-%s", sp.Station, feed, gt.Time.Round(granularity).UTC().Format("20060102T1504Z"))
+		if n := seen[key]; n > 0 {
+			keys[i] = fmt.Sprintf("%s-%d", key, n)
+		} else {
+			keys[i] = key
+		}
+		seen[key]++
+	}
+	return keys
+}
+
+// feedMergeGap returns the merge gap of the user, which may be nil.
+func feedMergeGap(user *data.User) time.Duration {
+	if user == nil {
+		return 0
+	}
+	return sessionOption(user.MergeGap)
+}
+
+// feedName returns a name for the feed of the user, which may be nil, that is
+// part of the keys of its good times.
+func feedName(user *data.User) string {
+	if user == nil {
+		return "public"
+	}
+	return fmt.Sprintf("user%d", user.ID)
+}
+
+// feedTitle titles the feed of the user, which may be nil.
+func feedTitle(user *data.User) string {
+	if user == nil || user.Name == "" {
+		return "Surfdash good times"
+	}
+	return fmt.Sprintf("Surfdash good times for %s", user.Name)
+}
+
+// trackEntry returns when the good time with the given key last changed, and
+// how many times it has. Good times that have not changed since they came
+// into the forecast were last updated then, so their time does not depend on
+// when the server saw them first. Later changes are remembered in the store,
+// if there is one, or until the server restarts.
+func (srv *Server) trackEntry(key string, gt meta.GoodTime, now time.Time) data.FeedEntry {
+	version := fmt.Sprintf("%d %d %q", gt.Time.Unix(), gt.Duration, meta.JoinReasons(gt.Reasons))
+	saved, err := srv.feedEntry(key)
+	if err == nil && saved.Version == version {
+		// Unchanged, so there is nothing to write.
+		return *saved
+	}
+	entry := data.FeedEntry{
+		Key:     key,
+		Version: version,
+		Updated: gt.Time.Add(-forecastLength).UTC().Truncate(time.Second),
+	}
+	if err == nil {
+		// The good time changed since it was saved.
+		entry.Updated = now.UTC().Truncate(time.Second)
+		entry.Sequence = saved.Sequence + 1
+	} else if !errors.Is(err, data.ErrNotFound) {
+		log.Printf("Failed to find feed entry %q: %v", key, err)
+		return entry
+	}
+	if err := srv.saveFeedEntry(&entry); err != nil {
+		log.Printf("Failed to save feed entry %q: %v", key, err)
+	}
+	return entry
+}
+
+// feedEntry finds the feed entry with the key in the store, or in memory if
+// there is no store.
+func (srv *Server) feedEntry(key string) (*data.FeedEntry, error) {
+	if srv.Store != nil {
+		return srv.Store.FeedEntry(key)
+	}
+	saved, ok := srv.entryUpdates.Get(key)
+	if !ok {
+		return nil, data.ErrNotFound
+	}
+	var entry data.FeedEntry
+	if err := json.Unmarshal(saved, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// saveFeedEntry saves the feed entry in the store, or in memory if there is no
+// store.
+func (srv *Server) saveFeedEntry(entry *data.FeedEntry) error {
+	if srv.Store != nil {
+		return srv.Store.SaveFeedEntry(entry)
+	}
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	srv.entryUpdates.Set(entry.Key, blob)
+	return nil
+}
+
+// makeAtomHandler serves good times as an Atom feed. With the token
+// parameter, the feed uses that user's preferences, and with the spot
+// parameter, it is of that spot.
+func (srv *Server) makeAtomHandler(redirectPrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, sp, goodTimes, ok := srv.feedGoodTimes(w, r)
+		if !ok {
+			return
+		}
+
+		base := baseURL(r, redirectPrefix)
+		self := base + "/api/v2/goodtimes.atom"
+		params := url.Values{}
+		if token := r.FormValue("token"); token != "" {
+			params.Set("token", token)
+		}
+		if s := r.FormValue("spot"); s != "" {
+			params.Set("spot", s)
+		}
+		if len(params) > 0 {
+			self += "?" + params.Encode()
+		}
+		name := feedName(user)
+		feed := atom.Feed{
+			ID:     fmt.Sprintf("urn:surfdash:%d-%s", sp.Station, name),
+			Title:  feedTitle(user),
+			Author: &atom.Person{Name: "surfdash"},
+			Links: []atom.Link{
+				{Rel: "self", Type: "application/atom+xml", Href: self},
+				{Rel: "alternate", Type: "text/html", Href: base + "/"},
+			},
+		}
+		now := srv.Clock.Now()
+		cal := srv.calendarAt(sp)
+		for i, key := range goodTimeKeys(goodTimes, sp, name, feedMergeGap(user)) {
+			gt := goodTimes[i]
+			entry := atom.Entry{
+				ID:      "urn:surfdash:" + key,
+				Title:   fmt.Sprintf("%s %s at %s", gt.Time.In(cal.Location).Format("Mon 01/02"), gt.TimeRangeIn(i18n.English, cal), sp.Name),
+				Updated: srv.trackEntry(key, gt, now).Updated,
+				Summary: meta.JoinReasons(gt.Reasons),
+			}
+			start := url.QueryEscape(gt.Time.Format(time.RFC3339))
+			// Charts and good times of the entry use the preferences of the
+			// feed.
+			token := ""
+			if t := r.FormValue("token"); t != "" {
+				token = "&token=" + url.QueryEscape(t)
+			}
+			if sp.Station == defaultSpot.Station {
+				entry.Links = []atom.Link{
+					{Rel: "enclosure", Type: "image/svg+xml", Href: base + "/api/v2/tide_image?t=" + start + token},
+					{Rel: "alternate", Type: "text/html", Href: base + "/?start=" + start},
+				}
+			} else {
+				// The pages of the site are of the default spot only.
+				entry.Links = []atom.Link{
+					{Rel: "alternate", Type: "application/json", Href: fmt.Sprintf("%s/api/v3/goodtimes?spot=%d&start=%s&days=1%s", base, sp.Station, start, token)},
+				}
+			}
+			feed.Entries = append(feed.Entries, entry)
+		}
+		if len(feed.Entries) == 0 {
+			feed.Updated = now.UTC().Truncate(time.Second)
+		}
+
+		var buf bytes.Buffer
+		if err := feed.Encode(&buf); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Failed to write feed: %+v", err)
+			log.Printf("Failed to write feed: %+v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
+
+// baseURL returns the absolute URL of the root of the site, without a
+// trailing slash.
+func baseURL(r *http.Request, redirectPrefix string) string {
+	scheme := "https"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if r.TLS == nil {
+		scheme = "http"
+	}
+	return strings.TrimSuffix(scheme+"://"+r.Host+pathJoinPreservePrefix(redirectPrefix, "/"), "/")
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/meta"
+)
+
+// memoryStore is a data.Store in memory.
+type memoryStore struct {
+	users   []*data.User
+	entries map[string]data.FeedEntry
+	// entryWrites counts the calls to SaveFeedEntry.
+	entryWrites int
+}
+
+func (s *memoryStore) User(id uint) (*data.User, error) {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, data.ErrNotFound
+}
+
+func (s *memoryStore) UserByFeedToken(token string) (*data.User, error) {
+	for _, u := range s.users {
+		if u.FeedToken == token {
+			return u, nil
+		}
+	}
+	return nil, data.ErrNotFound
+}
+
+func (s *memoryStore) SaveUser(user *data.User) error {
+	if user.ID == 0 {
+		user.ID = uint(len(s.users) + 1)
+		s.users = append(s.users, user)
+	}
+	return nil
+}
+
+func (s *memoryStore) FeedEntry(key string) (*data.FeedEntry, error) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, data.ErrNotFound
+	}
+	return &entry, nil
+}
+
+func (s *memoryStore) SaveFeedEntry(entry *data.FeedEntry) error {
+	if s.entries == nil {
+		s.entries = map[string]data.FeedEntry{}
+	}
+	s.entryWrites++
+	s.entries[entry.Key] = *entry
+	return nil
+}
+
+func TestTrackEntry(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC)
+	gt := meta.GoodTime{Time: now.Add(50 * time.Hour), Duration: time.Hour}
+	listed := gt.Time.Add(-forecastLength)
+	changed := gt
+	changed.Duration = 2 * time.Hour
+
+	store := &memoryStore{}
+	for _, step := range []struct {
+		name         string
+		srv          *Server
+		gt           meta.GoodTime
+		now          time.Time
+		want         time.Time
+		wantSequence int
+		// wantWrites is the number of writes to the store.
+		wantWrites int
+	}{
+		{"first seen", testServer(now), gt, now, listed, 0, 0},
+		{"seen by a server without a store", testServer(now.Add(time.Hour)), gt, now.Add(time.Hour), listed, 0, 0},
+		{"saved", &Server{Store: store}, gt, now, listed, 0, 1},
+		{"after a restart", &Server{Store: store}, gt, now.Add(time.Hour), listed, 0, 1},
+		{"changed", &Server{Store: store}, changed, now.Add(2 * time.Hour), now.Add(2 * time.Hour), 1, 2},
+		{"changed after a restart", &Server{Store: store}, changed, now.Add(3 * time.Hour), now.Add(2 * time.Hour), 1, 2},
+		{"changed back", &Server{Store: store}, gt, now.Add(4 * time.Hour), now.Add(4 * time.Hour), 2, 3},
+	} {
+		got := step.srv.trackEntry("key", step.gt, step.now)
+		if !got.Updated.Equal(step.want) || got.Sequence != step.wantSequence {
+			t.Errorf("%s: got updated %v, sequence %d; wanted %v, %d", step.name, got.Updated, got.Sequence, step.want, step.wantSequence)
+		}
+		if store.entryWrites != step.wantWrites {
+			t.Errorf("%s: store has %d writes, wanted %d", step.name, store.entryWrites, step.wantWrites)
+		}
+	}
+}
+
+func TestGoodTimeKeys(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2021, time.June, 1, h, m, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		name     string
+		starts   []time.Time
+		mergeGap time.Duration
+		want     []string
+	}{{
+		name:   "windows of a day",
+		starts: []time.Time{at(5, 20), at(14, 40)},
+		want:   []string{"9413745-public-20210601T0530Z", "9413745-public-20210601T1430Z"},
+	}, {
+		// The key of the second window does not depend on the first.
+		name:   "the first window is gone",
+		starts: []time.Time{at(14, 40)},
+		want:   []string{"9413745-public-20210601T1430Z"},
+	}, {
+		name:   "a window moves a little",
+		starts: []time.Time{at(5, 10), at(14, 50)},
+		want:   []string{"9413745-public-20210601T0500Z", "9413745-public-20210601T1500Z"},
+	}, {
+		name:     "a large merge gap",
+		starts:   []time.Time{at(5, 10), at(14, 50)},
+		mergeGap: 2 * time.Hour,
+		want:     []string{"9413745-public-20210601T0600Z", "9413745-public-20210601T1400Z"},
+	}, {
+		name:   "windows in the same half hour",
+		starts: []time.Time{at(5, 20), at(5, 35)},
+		want:   []string{"9413745-public-20210601T0530Z", "9413745-public-20210601T0530Z-1"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var goodTimes []meta.GoodTime
+			for _, start := range tc.starts {
+				goodTimes = append(goodTimes, meta.GoodTime{Time: start, Duration: 10 * time.Minute})
+			}
+			got := goodTimeKeys(goodTimes, defaultSpot, "public", tc.mergeGap)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("keys (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFeedSpot(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location))
+	for _, tc := range []struct {
+		name     string
+		target   string
+		handler  http.HandlerFunc
+		wantCode int
+		want     string
+	}{{
+		name:     "atom of the default spot",
+		target:   "/api/v2/goodtimes.atom",
+		handler:  srv.makeAtomHandler(""),
+		wantCode: http.StatusOK,
+		want:     fmt.Sprintf("<id>urn:surfdash:%d-public</id>", defaultSpot.Station),
+	}, {
+		name:     "atom of a spot",
+		target:   fmt.Sprintf("/api/v2/goodtimes.atom?spot=%d", defaultSpot.Station),
+		handler:  srv.makeAtomHandler(""),
+		wantCode: http.StatusOK,
+		want:     fmt.Sprintf("<id>urn:surfdash:%d-public-20210601", defaultSpot.Station),
+	}, {
+		name:     "atom of an unknown spot",
+		target:   "/api/v2/goodtimes.atom?spot=1612340",
+		handler:  srv.makeAtomHandler(""),
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "calendar of a spot",
+		target:   fmt.Sprintf("/api/v2/goodtimes.ics?spot=%d", defaultSpot.Station),
+		handler:  srv.serveCalendar,
+		wantCode: http.StatusOK,
+		want:     fmt.Sprintf("UID:%d-public-20210601", defaultSpot.Station),
+	}, {
+		name:     "calendar of an unknown spot",
+		target:   "/api/v2/goodtimes.ics?spot=abc",
+		handler:  srv.serveCalendar,
+		wantCode: http.StatusNotFound,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if w.Code != tc.wantCode {
+				t.Fatalf("got code %d, wanted %d: %s", w.Code, tc.wantCode, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tc.want) {
+				t.Errorf("feed does not contain %q:\n%s", tc.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestFeedToken(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location)
+	store := &memoryStore{}
+	store.SaveUser(&data.User{FeedToken: "secret", MinTide: ptr(2.0), MaxTide: ptr(4.0)})
+	srv := testServer(now)
+	srv.Store = store
+	get := func(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get(srv.makeAtomHandler(""), "/api/v2/goodtimes.atom?token=secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	if want := `/api/v2/tide_image?t=2021-06-01T`; !strings.Contains(w.Body.String(), want) {
+		t.Fatalf("feed has no enclosure %s:\n%s", want, w.Body)
+	}
+	if strings.Count(w.Body.String(), `/api/v2/tide_image?t=`) != strings.Count(w.Body.String(), `&amp;token=secret"`) {
+		t.Errorf("enclosures do not all have the feed token:\n%s", w.Body)
+	}
+	writes := store.entryWrites
+	if writes == 0 {
+		t.Errorf("feed saved no entries")
+	}
+	get(srv.makeAtomHandler(""), "/api/v2/goodtimes.atom?token=secret")
+	get(srv.serveCalendar, "/api/v2/goodtimes.ics?token=secret")
+	if store.entryWrites != writes {
+		t.Errorf("unchanged feeds wrote %d entries", store.entryWrites-writes)
+	}
+
+	band := regexp.MustCompile(`<rect class="tide_band"[^>]*>`)
+	public := band.FindString(get(srv.serveTideImage, "/api/v2/tide_image").Body.String())
+	private := band.FindString(get(srv.serveTideImage, "/api/v2/tide_image?token=secret").Body.String())
+	if public == "" || private == "" || public == private {
+		t.Errorf("tide band with the token is %q, wanted one other than %q", private, public)
+	}
+	if w := get(srv.serveTideImage, "/api/v2/tide_image?token=nope"); w.Code != http.StatusNotFound {
+		t.Errorf("got code %d for an unknown token, wanted 404", w.Code)
+	}
+}
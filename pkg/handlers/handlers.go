@@ -1,22 +1,28 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spencer-p/surfdash/pkg/cache"
+	"github.com/spencer-p/surfdash/pkg/i18n"
 	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/metrics"
 	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
 	"github.com/spencer-p/surfdash/pkg/sunset"
 	"github.com/spencer-p/surfdash/pkg/timetricks"
 	"github.com/spencer-p/surfdash/pkg/visualize"
-
-	"github.com/gorilla/mux"
 )
 
 const (
@@ -25,27 +31,14 @@ const (
 	cacheTTL       = 1 * day
 )
 
-func Register(r *mux.Router, redirectPrefix string, content embed.FS) {
-	r.Handle("/api/v1/index", makeIndexHandler(content))
-	r.HandleFunc("/api/v1/goodtimes", serveGoodTimes)
-
-	ssIndex := makeServerSideIndex(content)
-	r.HandleFunc("/", ssIndex)
-	r.HandleFunc("/config", makeConfigTideParameters(redirectPrefix, content))
-	r.HandleFunc("/api/v2/index", ssIndex)
-	r.HandleFunc("/api/v2/goodtimes", serveGoodTimes2)
-	r.HandleFunc("/api/v2/tide_image", serveTideImage)
-
-	r.PathPrefix("/static/").Handler(http.FileServer(http.FS(content)))
-}
-
-func makeFetchGoodTimes() func(time.Duration) ([]meta.GoodTime, error) {
+func (srv *Server) makeFetchGoodTimes() func(time.Duration) ([]meta.GoodTime, error) {
 	// cache for an hour at a time.
 	timeCache := cache.NewTimed(1 * time.Hour)
 
 	return func(dur time.Duration) ([]meta.GoodTime, error) {
 		// serve cache version from memory if possible
-		key := timetricks.UniqueDay(time.Now()) + dur.String()
+		now := srv.calendar().Now()
+		key := timetricks.UniqueDay(now) + dur.String()
 		if cached, ok := timeCache.Get(key); ok {
 			var goodTimes []meta.GoodTime
 			if err := json.Unmarshal(cached, &goodTimes); err != nil {
@@ -56,19 +49,21 @@ func makeFetchGoodTimes() func(time.Duration) ([]meta.GoodTime, error) {
 		log.Println("No cache data")
 
 		query := noaa.PredictionQuery{
-			Start:    time.Now(),
+			Start:    now,
 			Duration: dur,
 			Station:  noaa.SantaCruz,
+			Location: defaultSpot.Place.Location,
 		}
 
-		preds, err := noaa.GetPredictions(&query)
+		preds, err := srv.Tides.GetPredictions(&query)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch from NOAA: %w", err)
 		}
 
-		sunevents := sunset.GetSunEvents(time.Now(), query.Duration, sunset.SantaCruz)
+		sunevents := sunset.GetSunEvents(now, query.Duration, sunset.SantaCruz)
 
-		goodTimes := meta.GoodTimes(meta.Conditions{preds, sunevents})
+		goodTimes := meta.GoodTimes(meta.Conditions{Tides: preds, SunEvents: sunevents})
+		setPrettyTimes(goodTimes, srv.calendar())
 
 		// save the result to cache asynchonously as it may block
 		go func() {
@@ -84,11 +79,9 @@ func makeFetchGoodTimes() func(time.Duration) ([]meta.GoodTime, error) {
 	}
 }
 
-var fetchGoodTimes = makeFetchGoodTimes()
-
-func serveGoodTimes(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) serveGoodTimes(w http.ResponseWriter, r *http.Request) {
 	// get the good times
-	goodTimes, err := fetchGoodTimes(forecastLength)
+	goodTimes, err := srv.fetchGoodTimes(forecastLength)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Failed to fetch good times: %+v", err)
@@ -108,7 +101,7 @@ func serveGoodTimes(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "text/plain")
 		w.WriteHeader(http.StatusOK)
 		for i, gt := range goodTimes {
-			fmt.Fprintf(w, "%s", gt.String())
+			fmt.Fprintf(w, "%s", gt.StringIn(i18n.English, srv.calendar()))
 			if i+1 < len(goodTimes) {
 				fmt.Fprintf(w, "\n")
 			}
@@ -137,84 +130,407 @@ func makeIndexHandler(content embed.FS) http.Handler {
 	})
 }
 
-func serveGoodTimes2(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) serveGoodTimes2(w http.ResponseWriter, r *http.Request) {
 	// get the good times
-	goodTimes, err := fetchGoodTimes2(forecastLength)
+	goodTimes, conditions, err := srv.fetchGoodTimes2(defaultSpot, srv.Clock.Now(), forecastLength, meta.Options{})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Failed to fetch good times: %+v", err)
 		log.Printf("Failed to fetch good times: %+v", err)
 		return
 	}
+	goodTimes, err = rankGoodTimes(r, goodTimes)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "%v", err)
+		return
+	}
 
 	// serve result
 	outputFormat := r.FormValue("o")
 	if outputFormat == "json" {
+		setPrettyTimes(goodTimes, srv.calendar())
 		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(goodTimes); err != nil {
 			log.Printf("Failed to encode JSON result: %+v", err)
 		}
 	} else {
-		w.Header().Add("Content-Type", "text/plain")
+		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
+		if wantsTerminal(r) {
+			writeTerminalChart(w, r, conditions, goodTimes)
+		}
+		session, _ := srv.Sessions.Get(r, sessionName)
+		locale := localeFor(r, session)
 		for i, gt := range goodTimes {
-			fmt.Fprintf(w, "%s", gt.String())
+			fmt.Fprintf(w, "%s", gt.StringIn(locale, srv.calendar()))
 			if i+1 < len(goodTimes) {
 				fmt.Fprintf(w, "\n")
 			}
 		}
 		if len(goodTimes) == 0 {
-			fmt.Fprintf(w, "No good times found.")
+			fmt.Fprintf(w, "%s", locale.T("No good times found."))
+		}
+	}
+}
+
+// setPrettyTimes sets the pretty time of each good time relative to today on
+// the calendar.
+func setPrettyTimes(goodTimes []meta.GoodTime, cal timetricks.Calendar) {
+	for i := range goodTimes {
+		goodTimes[i].UpdatePrettyTime(cal)
+	}
+}
+
+// rankGoodTimes filters good times by the min_score parameter and orders them
+// by the sort parameter, which is either "time" (the default) or "score".
+func rankGoodTimes(r *http.Request, goodTimes []meta.GoodTime) ([]meta.GoodTime, error) {
+	if minScore := r.FormValue("min_score"); minScore != "" {
+		min, err := strconv.Atoi(minScore)
+		if err != nil {
+			return nil, fmt.Errorf("min_score %q is not an integer", minScore)
 		}
+		goodTimes = meta.FilterByScore(goodTimes, min)
+	}
+	switch order := r.FormValue("sort"); order {
+	case "", "time":
+	case "score":
+		meta.SortByScore(goodTimes)
+	default:
+		return nil, fmt.Errorf("cannot sort by %q", order)
 	}
+	return goodTimes, nil
 }
 
-func fetchGoodTimes2(dur time.Duration) ([]meta.GoodTime, error) {
+func (srv *Server) fetchGoodTimes2(sp spot, start time.Time, dur time.Duration, opts meta.Options) ([]meta.GoodTime, meta.Conditions, error) {
+	// Days begin at midnight at the spot, not wherever start came from.
+	start = start.In(sp.Place.Location)
+	model := splines.ModelFor(sp.Station)
 	query := noaa.PredictionQuery{
-		Start:    time.Now(),
+		Start:    start,
 		Duration: dur,
-		Station:  noaa.SantaCruz,
+		Station:  sp.Station,
+		Interval: model.Interval(),
+		Location: sp.Place.Location,
 	}
 
-	preds, err := noaa.GetPredictions(&query)
+	preds, err := srv.Tides.GetPredictions(&query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch from NOAA: %w", err)
+		return nil, meta.Conditions{}, fmt.Errorf("failed to fetch from NOAA: %w", err)
 	}
 
-	sunevents := sunset.GetSunEvents(time.Now(), query.Duration, sunset.SantaCruz)
+	sunevents := sunset.GetSunEvents(start, query.Duration, sp.Place)
 
-	goodTimes := meta.GoodTimes2(meta.Conditions{preds, sunevents}, meta.Options{})
+	conditions := meta.Conditions{Tides: preds, SunEvents: sunevents, Model: model}
+	goodTimes := meta.GoodTimes2(conditions, opts)
 
-	return goodTimes, nil
+	return goodTimes, conditions, nil
+}
+
+// wantsTerminal returns true if the client is a terminal program like curl,
+// which would rather have a text chart than HTML or an image.
+func wantsTerminal(r *http.Request) bool {
+	if r.FormValue("chart") == "text" {
+		return true
+	}
+	if accept := r.Header.Get("Accept"); strings.Contains(accept, "text/plain") && !strings.Contains(accept, "text/html") {
+		return true
+	}
+	ua := r.UserAgent()
+	return strings.HasPrefix(ua, "curl/") ||
+		strings.HasPrefix(ua, "Wget/") ||
+		strings.HasPrefix(ua, "HTTPie/")
 }
 
-func serveTideImage(w http.ResponseWriter, r *http.Request) {
+// writeTerminalChart writes a text chart of the tide and good times. The
+// width parameter sets its width in characters.
+func writeTerminalChart(w io.Writer, r *http.Request, c meta.Conditions, goodTimes []meta.GoodTime) {
+	spl := c.Model.Interpolate(c.Tides)
+	if len(spl) == 0 {
+		return
+	}
+	width := visualize.DefaultTerminalWidth
+	if v, err := strconv.Atoi(r.FormValue("width")); err == nil && v > 0 && v <= maxTerminalWidth {
+		width = v
+	}
+	chart := visualize.NewTerminal(spl, c.SunEvents, spl[0].Start, spl[len(spl)-1].End)
+	chart.SetSize(width, visualize.DefaultTerminalRows)
+	chart.SetGoodTimes(goodTimes)
+	if _, err := chart.Encode(w); err != nil {
+		log.Printf("Failed to draw terminal chart: %v", err)
+		metrics.ObserveRenderFailure("terminal")
+		return
+	}
+	fmt.Fprintln(w)
+}
+
+// maxTerminalWidth is the widest text chart, in characters.
+const maxTerminalWidth = 500
+
+// maxImageSize is the largest width or height of a tide image, in pixels.
+const maxImageSize = 4000
+
+// setImageGeometry sets the size and range of img from the width, height, low,
+// and high parameters of r. Each is optional, but low and high must be given
+// together.
+func setImageGeometry(img *visualize.Tidal, r *http.Request) error {
+	width, height := visualize.DefaultWidth, visualize.DefaultHeight
+	for _, dim := range []struct {
+		name string
+		dst  *int
+	}{{"width", &width}, {"height", &height}} {
+		v := r.FormValue(dim.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxImageSize {
+			return fmt.Errorf("%s %q must be between 1 and %d", dim.name, v, maxImageSize)
+		}
+		*dim.dst = n
+	}
+	img.SetSize(width, height)
+
+	low, high := r.FormValue("low"), r.FormValue("high")
+	if low == "" && high == "" {
+		return nil
+	}
+	lowf, err := strconv.ParseFloat(low, 64)
+	if err != nil {
+		return fmt.Errorf("low %q is not a number", low)
+	}
+	highf, err := strconv.ParseFloat(high, 64)
+	if err != nil {
+		return fmt.Errorf("high %q is not a number", high)
+	}
+	if highf <= lowf {
+		return fmt.Errorf("high %v must be above low %v", highf, lowf)
+	}
+	img.SetRange(lowf, highf)
+	return nil
+}
+
+// predictionsFor returns the predictions that model interpolates. hilo must be
+// the high and low tide predictions for query, and are reused if the model
+// interpolates those.
+func (srv *Server) predictionsFor(model splines.Model, query noaa.PredictionQuery, hilo noaa.Predictions) (noaa.Predictions, error) {
+	if model.Interval() == noaa.HiLo {
+		return hilo, nil
+	}
+	query.Interval = model.Interval()
+	return srv.Tides.GetPredictions(&query)
+}
+
+func (srv *Server) serveTideImage(w http.ResponseWriter, r *http.Request) {
+	calendar := srv.calendar()
+	now := calendar.Now()
 	query := noaa.PredictionQuery{
-		Start:    time.Now().Add(-1 * 24 * time.Hour),
-		Duration: forecastLength + 24*time.Hour,
+		// Pad by a day on each side so every chart has a whole day of tide.
+		Start:    now.Add(-day),
+		Duration: forecastLength + 2*24*time.Hour,
 		Station:  noaa.SantaCruz,
+		Location: defaultSpot.Place.Location,
 	}
-	preds, err := noaa.GetPredictions(&query)
+	preds, err := srv.Tides.GetPredictions(&query)
 	if err != nil {
-		err := fmt.Errorf("failed to fetch from NOAA: %w", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to fetch good times: %+v", err)
-		log.Printf("Failed to fetch good times: %+v", err)
+		fmt.Fprintf(w, "Failed to fetch tides for the tide image from NOAA: %+v", err)
+		log.Printf("Failed to fetch tides for the tide image from NOAA: %+v", err)
 		return
 	}
 
-	sunevents := sunset.GetSunEvents(time.Now(), query.Duration, sunset.SantaCruz)
+	sunevents := sunset.GetSunEvents(now, query.Duration, sunset.SantaCruz)
+
+	// Highlight the user's preferences and good times. Feed readers have no
+	// session, so feeds link to charts with the user's feed token instead.
+	session, _ := srv.Sessions.Get(r, sessionName)
+	opts, _ := srv.goodTimeOptionsFromSession(session)
+	if user, err := srv.feedUser(r); errors.Is(err, errUnknownFeed) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Unknown feed")
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to find feed: %+v", err)
+		log.Printf("Failed to find feed: %+v", err)
+		return
+	} else if user != nil {
+		opts = optionsForUser(user)
+	}
+	model := splines.ModelFor(query.Station)
+	modelPreds, err := srv.predictionsFor(model, query, preds)
+	if err != nil {
+		log.Printf("Failed to fetch predictions for good times: %v", err)
+		modelPreds = nil
+	}
+	goodTimes := meta.GoodTimes2(meta.Conditions{Tides: modelPreds, SunEvents: sunevents, Model: model}, opts)
 
 	date := r.FormValue("t")
 	t, err := time.Parse(time.RFC3339, date)
 	if err != nil {
 		log.Printf("Failed to read time %q: %v", date, err)
-		t = time.Now()
+		t = now
 	}
 	img := visualize.NewTidal(preds, sunevents)
-	img.SetDate(t)
-	w.Header().Add("Content-Type", "image/svg+xml")
+	img.SetModel(model, modelPreds)
+	img.SetOptions(opts)
+	img.SetGoodTimes(goodTimes)
+	img.SetDate(calendar.Midnight(t))
+	if err := setImageGeometry(img, r); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "%v", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	var contentType string
+	switch format := r.FormValue("format"); format {
+	case "", "svg":
+		contentType = "image/svg+xml"
+		_, err = img.Encode(&buf)
+	case "png":
+		contentType = "image/png"
+		err = img.EncodePNG(&buf)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unknown image format %q", format)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to draw tide image: %+v", err)
+		metrics.ObserveRenderFailure("tide")
+		if contentType != "image/svg+xml" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Failed to draw tide image: %+v", err)
+			return
+		}
+		// The SVG is a placeholder. Show it, but do not let it be cached in
+		// place of the real chart.
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(buf.Bytes())
+		return
+	}
+	writeCacheable(w, r, contentType, buf.Bytes())
+}
+
+// maxOverviewDays is the longest overview chart, in days.
+const maxOverviewDays = 28
+
+// serveOverview serves a chart of several days. The chart parameter is either
+// "strip" (the default) or "heatmap", days is the number of days (7 by
+// default), and start is an RFC3339 time on the first day.
+func (srv *Server) serveOverview(w http.ResponseWriter, r *http.Request) {
+	calendar := srv.calendar()
+	start := calendar.Now()
+	if s := r.FormValue("start"); s != "" {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Failed to read start %q: %v", s, err)
+			return
+		}
+		start = parsed
+	}
+	days := int(forecastLength / day)
+	if d := r.FormValue("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed <= 0 || parsed > maxOverviewDays {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "days %q must be between 1 and %d", d, maxOverviewDays)
+			return
+		}
+		days = parsed
+	}
+	chart := r.FormValue("chart")
+	if chart == "" {
+		chart = "strip"
+	}
+	if chart != "strip" && chart != "heatmap" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unknown chart %q", chart)
+		return
+	}
+
+	start = calendar.Midnight(start)
+	query := noaa.PredictionQuery{
+		// Pad by a day on each side so the tide is continuous at the edges.
+		Start:    start.Add(-day),
+		Duration: time.Duration(days+2) * day,
+		Station:  noaa.SantaCruz,
+		Location: defaultSpot.Place.Location,
+	}
+	preds, err := srv.Tides.GetPredictions(&query)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to fetch tides for the overview from NOAA: %+v", err)
+		log.Printf("Failed to fetch tides for the overview from NOAA: %+v", err)
+		return
+	}
+	sunevents := sunset.GetSunEvents(start, time.Duration(days)*day, sunset.SantaCruz)
+
+	session, _ := srv.Sessions.Get(r, sessionName)
+	opts, _ := srv.goodTimeOptionsFromSession(session)
+	model := splines.ModelFor(query.Station)
+	modelPreds, err := srv.predictionsFor(model, query, preds)
+	if err != nil {
+		log.Printf("Failed to fetch predictions for good times: %v", err)
+		modelPreds = nil
+	}
+	goodTimes := meta.GoodTimes2(meta.Conditions{Tides: modelPreds, SunEvents: sunevents, Model: model}, opts)
+
+	body, err := drawOverview(chart, localeFor(r, session), model, modelPreds, sunevents, goodTimes, start, days)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Failed to draw overview: %+v", err)
+		log.Printf("Failed to draw overview: %+v", err)
+		metrics.ObserveRenderFailure(chart)
+		return
+	}
+	writeCacheable(w, r, "image/svg+xml", body)
+}
+
+// drawOverview draws an overview chart of the given kind as an SVG, labelled
+// in locale. The tide is interpolated from preds by model.
+func drawOverview(chart string, locale i18n.Locale, model splines.Model, preds noaa.Predictions, sunevents sunset.SunEvents, goodTimes []meta.GoodTime, start time.Time, days int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch chart {
+	case "heatmap":
+		heatmap := visualize.NewHeatmap(goodTimes, start, days)
+		heatmap.SetLocale(locale)
+		_, err = heatmap.Encode(&buf)
+	default:
+		strip := visualize.NewStrip(preds, sunevents, start, days)
+		strip.SetModel(model, preds)
+		strip.SetGoodTimes(goodTimes)
+		strip.SetLocale(locale)
+		_, err = strip.Encode(&buf)
+	}
+	return buf.Bytes(), err
+}
+
+// imageMaxAge is how long clients may cache a tide image. Images only change
+// when the predictions or the user's preferences do.
+const imageMaxAge = time.Hour
+
+// writeCacheable writes body with headers that let clients cache it, or no
+// body at all if the client already has it.
+func writeCacheable(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(body))
+	w.Header().Set("ETag", etag)
+	// Images reflect the preferences in the session cookie.
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(imageMaxAge.Seconds())))
+	w.Header().Set("Vary", "Cookie")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 	w.WriteHeader(http.StatusOK)
-	img.Encode(w)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/cache"
+	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/timetricks"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// Server serves surfdash. Its fields are the services it depends on.
+type Server struct {
+	// Store keeps the preferences of users. It is optional. Without it,
+	// preferences are kept in the session cookie and there are no per-user
+	// feeds.
+	Store data.Store
+	// Tides fetches tide predictions.
+	Tides noaa.Client
+	// Clock tells the current time.
+	Clock timetricks.Clock
+	// Sessions keeps the sessions of visitors.
+	Sessions sessions.Store
+
+	// fetchGoodTimes serves the v1 API from a cache.
+	fetchGoodTimes func(time.Duration) ([]meta.GoodTime, error)
+	// entryUpdates remembers when each good time in a feed last changed, by
+	// key, if there is no store. Good times are gone from the forecast before
+	// they expire.
+	entryUpdates *cache.Timed
+}
+
+// NewServer returns a server of user preferences in store, which may be nil,
+// tides from NOAA, the system clock, and sessions in cookies.
+func NewServer(store data.Store) *Server {
+	srv := &Server{
+		Store:        store,
+		Tides:        noaa.API,
+		Clock:        timetricks.System,
+		Sessions:     newCookieStore(),
+		entryUpdates: cache.NewTimed(forecastLength + day),
+	}
+	srv.fetchGoodTimes = srv.makeFetchGoodTimes()
+	return srv
+}
+
+// Register adds the routes of the server to r.
+func (srv *Server) Register(r *mux.Router, redirectPrefix string, content embed.FS) {
+	r.Handle("/api/v1/index", makeIndexHandler(content))
+	r.HandleFunc("/api/v1/goodtimes", srv.serveGoodTimes)
+
+	ssIndex := srv.makeServerSideIndex(content)
+	r.HandleFunc("/", ssIndex)
+	r.HandleFunc("/config", srv.makeConfigTideParameters(redirectPrefix, content))
+	r.HandleFunc("/api/v2/index", ssIndex)
+	r.HandleFunc("/api/v2/goodtimes", srv.serveGoodTimes2)
+	r.HandleFunc("/api/v2/tide_image", srv.serveTideImage)
+	r.HandleFunc("/api/v2/overview", srv.serveOverview)
+	r.HandleFunc("/api/v2/goodtimes.ics", srv.serveCalendar)
+	r.HandleFunc("/api/v2/goodtimes.atom", srv.makeAtomHandler(redirectPrefix))
+
+	v3 := r.PathPrefix("/api/v3").Subrouter()
+	v3.HandleFunc("/goodtimes", srv.serveGoodTimes3)
+	v3.HandleFunc("/tides", srv.serveTides3)
+	v3.HandleFunc("/sun", srv.serveSun3)
+	v3.HandleFunc("/openapi.json", makeOpenAPIHandler(content))
+	v3.NotFoundHandler = http.HandlerFunc(serveAPINotFound)
+
+	r.PathPrefix("/static/").Handler(http.FileServer(http.FS(content)))
+}
+
+// newCookieStore returns a session store that keeps sessions in cookies
+// signed and encrypted by keys from the environment.
+func newCookieStore() *sessions.CookieStore {
+	store := &sessions.CookieStore{
+		Codecs: securecookie.CodecsFromPairs(
+			getSessionKey(),
+			getEncryptionKey(),
+			getSessionKey(),
+			nil,
+			[]byte("deadbeef"), // TODO: Remove.
+			nil,
+		),
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   defaultMaxAge,
+			Secure:   true,
+			HttpOnly: true,
+		},
+	}
+	store.MaxAge(defaultMaxAge)
+	return store
+}
+
+// loadUser returns the user of the session, or nil if there is none. Users
+// come from the store, or from the session itself if there is no store.
+func (srv *Server) loadUser(session *sessions.Session) (*data.User, error) {
+	if srv.Store == nil {
+		blob, ok := session.Values[sessionPreferences].(string)
+		if !ok {
+			return nil, nil
+		}
+		var user data.User
+		if err := json.Unmarshal([]byte(blob), &user); err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	id, ok := session.Values[userID].(uint)
+	if !ok {
+		return nil, nil
+	}
+	return srv.Store.User(id)
+}
+
+// saveUser saves the user for the session. The session must be saved after.
+func (srv *Server) saveUser(session *sessions.Session, user *data.User) error {
+	if srv.Store == nil {
+		blob, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		session.Values[sessionPreferences] = string(blob)
+		return nil
+	}
+
+	if err := srv.Store.SaveUser(user); err != nil {
+		return err
+	}
+	session.Values[userID] = user.ID
+	return nil
+}
+
+// touchUser records that the user was seen now.
+func (srv *Server) touchUser(user *data.User) {
+	// Log the time since we last saw the user.
+	if !user.LastSeen.IsZero() {
+		sinceLastUpdate := srv.Clock.Now().Sub(user.LastSeen)
+		log.Printf("User %d (%q) was last seen %s ago", user.ID, user.Name, sinceLastUpdate)
+	}
+	user.LastSeen = srv.Clock.Now()
+	if srv.Store != nil {
+		if err := srv.Store.SaveUser(user); err != nil {
+			log.Printf("Failed to save user %d: %v", user.ID, err)
+		}
+	}
+}
+
+// calendar reckons days at the default spot by the server's clock.
+func (srv *Server) calendar() timetricks.Calendar {
+	return srv.calendarAt(defaultSpot)
+}
+
+// calendarAt reckons days at the spot by the server's clock.
+func (srv *Server) calendarAt(sp spot) timetricks.Calendar {
+	return timetricks.Calendar{Clock: srv.Clock, Location: sp.Place.Location}
+}
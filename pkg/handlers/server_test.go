@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/sessions"
+	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/timetricks"
+)
+
+// fakeTides predicts a high tide of 5ft and a low tide of -1ft every twelve
+// hours, with lows at midnight and noon UTC.
+func fakeTides(q *noaa.PredictionQuery) (noaa.Predictions, error) {
+	var preds noaa.Predictions
+	for t := q.Start.Truncate(6 * time.Hour); t.Before(q.Start.Add(q.Duration)); t = t.Add(6 * time.Hour) {
+		p := noaa.Prediction{Time: noaa.Time(t), Height: -1, Type: noaa.LowTide}
+		if t.UTC().Hour()%12 != 0 {
+			p.Height, p.Type = 5, noaa.HighTide
+		}
+		preds = append(preds, p)
+	}
+	return preds, nil
+}
+
+func testServer(now time.Time) *Server {
+	srv := NewServer(nil)
+	srv.Tides = noaa.ClientFunc(fakeTides)
+	srv.Clock = timetricks.Fixed(now)
+	return srv
+}
+
+func TestServeGoodTimes3WithoutStore(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location)
+	srv := testServer(now)
+
+	for _, tc := range []struct {
+		name       string
+		target     string
+		wantCode   int
+		wantStarts []string
+	}{{
+		// Low tides are at 5AM and 5PM in California.
+		name:       "public good times",
+		target:     "/api/v3/goodtimes?days=2",
+		wantCode:   http.StatusOK,
+		wantStarts: []string{"Jun 1 05:21", "Jun 1 14:40", "Jun 2 05:21", "Jun 2 14:40"},
+	}, {
+		name:     "unknown token",
+		target:   "/api/v3/goodtimes?token=abc",
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "bad range",
+		target:   "/api/v3/goodtimes?days=100",
+		wantCode: http.StatusBadRequest,
+	}, {
+		// The tide only falls from 11AM to 5PM.
+		name:       "falling on tuesdays",
+		target:     "/api/v3/goodtimes?days=2&direction=falling&weekdays=tue",
+		wantCode:   http.StatusOK,
+		wantStarts: []string{"Jun 1 14:40"},
+	}, {
+		name:     "bad direction",
+		target:   "/api/v3/goodtimes?direction=sideways",
+		wantCode: http.StatusBadRequest,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.serveGoodTimes3(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if w.Code != tc.wantCode {
+				t.Fatalf("got code %d, wanted %d: %s", w.Code, tc.wantCode, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var got apiGoodTimes
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode good times: %v", err)
+			}
+			if !got.Start.Equal(now) {
+				t.Errorf("got start %v, wanted the time of the clock %v", got.Start, now)
+			}
+			var starts []string
+			for _, gt := range got.GoodTimes {
+				starts = append(starts, gt.Start.In(defaultSpot.Place.Location).Format("Jan 2 15:04"))
+			}
+			if diff := cmp.Diff(tc.wantStarts, starts); diff != "" {
+				t.Errorf("good time starts (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeTides3OtherZone(t *testing.T) {
+	// NOAA gives times in GMT when asked, whatever the zone of the station.
+	fakeNOAA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tz := r.FormValue("time_zone"); tz != "gmt" {
+			t.Errorf("got time_zone %q, wanted gmt", tz)
+		}
+		fmt.Fprint(w, `{"predictions":[
+			{"t":"2021-06-01 12:00", "v":"-0.500", "type":"L"},
+			{"t":"2021-06-01 18:15", "v":"2.100", "type":"H"}]}`)
+	}))
+	defer fakeNOAA.Close()
+	defer func(u url.URL) { noaa.NOAA_URL = u }(noaa.NOAA_URL)
+	u, err := url.Parse(fakeNOAA.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noaa.NOAA_URL.Scheme, noaa.NOAA_URL.Host = u.Scheme, u.Host
+
+	srv := testServer(time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+	srv.Tides = noaa.API
+	w := httptest.NewRecorder()
+	// Honolulu is ten hours behind UTC, and not in the zone of the server.
+	srv.serveTides3(w, httptest.NewRequest(http.MethodGet, "/api/v3/tides?spot=1612340&start=2021-06-01T00:00:00-10:00&days=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	var got apiTides
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode tides: %v", err)
+	}
+	want := []apiTideEvent{
+		{Time: time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC), Height: -0.5, Type: "low"},
+		{Time: time.Date(2021, time.June, 1, 18, 15, 0, 0, time.UTC), Height: 2.1, Type: "high"},
+	}
+	if diff := cmp.Diff(want, got.Events, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("tide events (-want,+got): %s", diff)
+	}
+}
+
+func TestCookiePreferences(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC))
+	r := httptest.NewRequest(http.MethodGet, "/config", nil)
+	session := sessions.NewSession(srv.Sessions, sessionName)
+
+	if user, err := srv.loadUser(session); err != nil || user != nil {
+		t.Fatalf("loadUser of a new session = %v, %v; wanted no user", user, err)
+	}
+
+	want := &data.User{Name: "kook", MinTide: ptr(0.5), MinDuration: 30 * time.Minute}
+	if err := srv.saveUser(session, want); err != nil {
+		t.Fatalf("saveUser failed: %v", err)
+	}
+	if _, ok := session.Values[userID]; ok {
+		t.Errorf("saveUser without a store set a user ID")
+	}
+	got, err := srv.loadUser(session)
+	if err != nil {
+		t.Fatalf("loadUser failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("loadUser (-want,+got): %s", diff)
+	}
+
+	if _, err := srv.feedUser(r); err != nil {
+		t.Errorf("feedUser without a token failed: %v", err)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/api/v2/goodtimes.ics?token=abc", nil)
+	if _, err := srv.feedUser(r); err != errUnknownFeed {
+		t.Errorf("feedUser without a store = %v, wanted %v", err, errUnknownFeed)
+	}
+}
+
+func TestChartsInServerZone(t *testing.T) {
+	// 3AM in UTC and noon in Tokyo on June 2 is still 8PM on June 1 in
+	// California, so every chart starts on June 1.
+	now := time.Date(2021, time.June, 2, 3, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+
+	for _, loc := range []*time.Location{time.UTC, tokyo} {
+		time.Local = loc
+		srv := testServer(now)
+		for _, tc := range []struct {
+			name    string
+			handler http.HandlerFunc
+			target  string
+			want    string
+		}{
+			{"tide image", srv.serveTideImage, "/api/v2/tide_image", `>Tide chart for Tuesday, June 1</title>`},
+			{"strip", srv.serveOverview, "/api/v2/overview?days=2", `aria-label="Tide chart for 2 days from Tuesday, June 1"`},
+			{"heatmap", srv.serveOverview, "/api/v2/overview?days=2&chart=heatmap", `aria-label="Good times by hour for 2 days from Tuesday, June 1"`},
+		} {
+			t.Run(loc.String()+" "+tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				tc.handler(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+				if w.Code != http.StatusOK {
+					t.Fatalf("got code %d: %s", w.Code, w.Body)
+				}
+				if body := w.Body.String(); !strings.Contains(body, tc.want) || strings.Contains(body, "no_data") {
+					t.Errorf("chart is not of June 1 at the spot, wanted %s:\n%s", tc.want, body)
+				}
+			})
+		}
+	}
+}
+
+func TestServeTideImageCaching(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location))
+	get := func(target, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		srv.serveTideImage(w, r)
+		return w
+	}
+
+	w := get("/api/v2/tide_image?format=png", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("got Content-Type %q, wanted image/png", got)
+	}
+	if _, err := png.Decode(w.Body); err != nil {
+		t.Errorf("failed to decode PNG: %v", err)
+	}
+	if got, want := w.Header().Get("Cache-Control"), "private, max-age=3600"; got != want {
+		t.Errorf("got Cache-Control %q, wanted %q", got, want)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("PNG has no ETag")
+	}
+
+	w = get("/api/v2/tide_image?format=png", etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("got code %d and %d bytes for a known ETag, wanted 304 and none", w.Code, w.Body.Len())
+	}
+	w = get("/api/v2/tide_image?format=png", `"stale"`)
+	if w.Code != http.StatusOK {
+		t.Errorf("got code %d for a stale ETag, wanted 200", w.Code)
+	}
+	if etag == get("/api/v2/tide_image", "").Header().Get("ETag") {
+		t.Errorf("SVG and PNG have the same ETag")
+	}
+
+	// There is no sun data for a chart before the predictions, so it is a
+	// placeholder.
+	w = get("/api/v2/tide_image?t=2021-05-01T00:00:00-07:00", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "no_data") {
+		t.Fatalf("got code %d, wanted a placeholder: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("placeholder has Cache-Control %q, wanted no-store", got)
+	}
+	if got := w.Header().Get("ETag"); got != "" {
+		t.Errorf("placeholder has ETag %s", got)
+	}
+	if w := get("/api/v2/tide_image?format=png&t=2021-05-01T00:00:00-07:00", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("got code %d for a PNG without data, wanted 500", w.Code)
+	}
+}
+
+func TestChartsWithoutTides(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, defaultSpot.Place.Location))
+	srv.Tides = noaa.ClientFunc(func(*noaa.PredictionQuery) (noaa.Predictions, error) {
+		return nil, fmt.Errorf("NOAA is down")
+	})
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"tide image", srv.serveTideImage, "Failed to fetch tides for the tide image from NOAA: NOAA is down"},
+		{"overview", srv.serveOverview, "Failed to fetch tides for the overview from NOAA: NOAA is down"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusInternalServerError || w.Body.String() != tc.want {
+				t.Errorf("got code %d and %q, wanted 500 and %q", w.Code, w.Body, tc.want)
+			}
+		})
+	}
+}
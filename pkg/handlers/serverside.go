@@ -2,97 +2,102 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/sha1"
 	"embed"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/i18n"
 	"github.com/spencer-p/surfdash/pkg/meta"
 	"github.com/spencer-p/surfdash/pkg/metrics"
 	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
 	"github.com/spencer-p/surfdash/pkg/sunset"
 	"github.com/spencer-p/surfdash/pkg/timetricks"
 	"github.com/spencer-p/surfdash/pkg/visualize"
 	"golang.org/x/crypto/pbkdf2"
 
-	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 )
 
 const (
 	sessionName       = "good-times"
 	sessionLastViewed = "last-viewed-referrer"
-	minTideCookieName = "minTide"
-	maxTideCookieName = "maxTide"
-	userID            = "userid"
+	// sessionOverview is the kind of overview chart to show atop the index,
+	// if any.
+	sessionOverview = "overview"
+	// sessionLanguage and sessionClock override the language of the
+	// Accept-Language header and the usual clock of the language.
+	sessionLanguage = "language"
+	sessionClock    = "clock"
+	// sessionPreferences holds the preferences of the user as JSON when there
+	// is no store of users.
+	sessionPreferences = "preferences"
+	minTideCookieName  = "minTide"
+	maxTideCookieName  = "maxTide"
+	userID             = "userid"
 	// See https://developer.chrome.com/blog/cookie-max-age-expires.
 	defaultMaxAge = 60 * 60 * 24 * 400 // 400 days in seconds.
 )
 
-var (
-	store = &sessions.CookieStore{
-		Codecs: securecookie.CodecsFromPairs(
-			getSessionKey(),
-			getEncryptionKey(),
-			getSessionKey(),
-			nil,
-			[]byte("deadbeef"), // TODO: Remove.
-			nil,
-		),
-		Options: &sessions.Options{
-			Path:     "/",
-			MaxAge:   defaultMaxAge,
-			Secure:   true,
-			HttpOnly: true,
-		},
-	}
-	db = data.PostgresFromEnvOrDie()
-)
-
-func init() {
-	store.MaxAge(defaultMaxAge)
-}
-
 type TemplateInput struct {
 	PresentationElements []PresentationElement
 	NextStart            string
 	PrevStart            string
 	Name                 string
+	// BestScore is the highest score of any good time on the page.
+	BestScore int
+	// Overview is a chart of the whole page. Optional.
+	Overview template.HTML
+	// Locale translates the page.
+	Locale i18n.Locale
+	// Calendar gives the days and times of the page.
+	Calendar timetricks.Calendar
 }
 
 type PresentationElement struct {
 	Date      string
 	GoodTimes []meta.GoodTime
 	TideImage template.HTML
+	// TideSummary describes TideImage for readers that cannot see it.
+	TideSummary []visualize.SummaryRow
 }
 
 // serverSideIndex serves a good times page fully rendered on the server.
-func makeServerSideIndex(content embed.FS) http.HandlerFunc {
+func (srv *Server) makeServerSideIndex(content embed.FS) http.HandlerFunc {
 	indexTemplate := template.Must(template.ParseFS(content, "static/index.template.html"))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, _ := store.Get(r, sessionName)
+		session, _ := srv.Sessions.Get(r, sessionName)
 		metrics.ObserveUserRequest(session.Values[userID])
 		session.Values[sessionLastViewed] = r.URL.String()
 		if err := session.Save(r, w); err != nil {
 			log.Println("save session err", err)
 		}
 
-		date := time.Now()
+		// Reckon days at the spot, whatever the zone of the server or the
+		// start parameter.
+		calendar := srv.calendar()
+		date := calendar.Now()
 		startString := r.FormValue("start")
 		if startString != "" {
 			parsed, err := time.Parse(time.RFC3339, startString)
 			if err != nil {
 				log.Printf("Failed to read time %q: %v", startString, err)
 			} else {
-				date = parsed
+				date = parsed.In(calendar.Location)
 			}
 		}
 
@@ -100,10 +105,11 @@ func makeServerSideIndex(content embed.FS) http.HandlerFunc {
 		query := noaa.PredictionQuery{
 			// Add extra padding of one day around tides to fill in gaps.
 			Start:    date.Add(-1 * 24 * time.Hour),
-			Duration: forecastLength + 24*time.Hour,
+			Duration: forecastLength + 2*24*time.Hour,
 			Station:  noaa.SantaCruz,
+			Location: defaultSpot.Place.Location,
 		}
-		preds, err := noaa.GetPredictions(&query)
+		preds, err := srv.Tides.GetPredictions(&query)
 		if err != nil {
 			err := fmt.Errorf("failed to fetch from NOAA: %w", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -114,19 +120,44 @@ func makeServerSideIndex(content embed.FS) http.HandlerFunc {
 
 		// Compute sun events, goodtimes, and set up tide images.
 		sunevents := sunset.GetSunEvents(date, query.Duration, sunset.SantaCruz)
+		model := splines.ModelFor(query.Station)
+		modelPreds, err := srv.predictionsFor(model, query, preds)
+		if err != nil {
+			err := fmt.Errorf("failed to fetch from NOAA: %w", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Failed to fetch good times: %+v", err)
+			log.Printf("Failed to fetch good times: %+v", err)
+			return
+		}
 		// Truncate the good times predictions to account for the
 		// extra data data from above.
-		trimIndex := lastIndexBefore(preds, timetricks.TrimClock(date.Add(forecastLength)))
-		opts, _ := goodTimeOptionsFromSession(session)
-		goodTimes := meta.GoodTimes2(meta.Conditions{preds[:trimIndex+1], sunevents}, opts)
+		trimIndex := modelPreds.IndexAtOrBefore(calendar.Midnight(date.Add(forecastLength)))
+		opts, _ := srv.goodTimeOptionsFromSession(session)
+		goodTimes := meta.GoodTimes2(meta.Conditions{Tides: modelPreds[:trimIndex+1], SunEvents: sunevents, Model: model}, opts)
+		locale := localeFor(r, session)
 		tideimages := visualize.NewTidal(preds, sunevents)
+		tideimages.SetModel(model, modelPreds)
+		tideimages.SetOptions(opts)
+		tideimages.SetGoodTimes(goodTimes)
+		tideimages.SetLocale(locale)
 
-		presElems := goodTimesToPresentationElements(tideimages, goodTimes)
+		presElems := goodTimesToPresentationElements(tideimages, goodTimes, locale, calendar)
 
 		tinput := TemplateInput{
 			PresentationElements: presElems,
+			BestScore:            bestScore(goodTimes),
 			NextStart:            date.Add(forecastLength).Format(time.RFC3339),
 			PrevStart:            date.Add(-1 * forecastLength).Format(time.RFC3339),
+			Locale:               locale,
+			Calendar:             calendar,
+		}
+		if chart, ok := session.Values[sessionOverview].(string); ok && chart != "" {
+			overview, err := drawOverview(chart, locale, model, modelPreds, sunevents, goodTimes, date, int(forecastLength/day))
+			if err != nil {
+				log.Printf("Failed to draw overview: %v", err)
+			} else {
+				tinput.Overview = template.HTML(overview)
+			}
 		}
 
 		w.Header().Add("Content-Type", "text/html")
@@ -140,33 +171,15 @@ func makeServerSideIndex(content embed.FS) http.HandlerFunc {
 func imgToString(img *visualize.Tidal, t time.Time) string {
 	img.SetDate(t)
 	var b bytes.Buffer
-	img.Encode(&b)
-	return b.String()
-}
-
-// TODO(spencer-p) Standardize these scattered binary search functions.
-func lastIndexBefore(preds noaa.Predictions, t time.Time) int {
-	left, right := 0, len(preds)
-	for right-left > 1 {
-		mid := (left + right) / 2
-		midt := preds[mid].T()
-		if midt.Before(t) {
-			left = mid
-		} else if midt.After(t) {
-			right = mid
-		} else if midt.Equal(t) {
-			return mid
-		}
-	}
-	ok := left < len(preds)
-	if !ok {
-		// Nothing found, just return last element
-		return len(preds) - 1
+	if _, err := img.Encode(&b); err != nil {
+		// Encode still wrote a placeholder to show.
+		log.Printf("Failed to draw tide image for %v: %v", t, err)
+		metrics.ObserveRenderFailure("tide")
 	}
-	return left
+	return b.String()
 }
 
-func goodTimesToPresentationElements(tideimages *visualize.Tidal, goodTimes []meta.GoodTime) []PresentationElement {
+func goodTimesToPresentationElements(tideimages *visualize.Tidal, goodTimes []meta.GoodTime, locale i18n.Locale, calendar timetricks.Calendar) []PresentationElement {
 	var f func(result []PresentationElement, goodTimes []meta.GoodTime) []PresentationElement
 	f = func(result []PresentationElement, goodTimes []meta.GoodTime) []PresentationElement {
 		if len(goodTimes) == 0 {
@@ -175,18 +188,19 @@ func goodTimesToPresentationElements(tideimages *visualize.Tidal, goodTimes []me
 
 		resultLen := len(result)
 		gt := goodTimes[0]
-		gt.UpdatePrettyTime()
+		gt.UpdatePrettyTime(calendar)
 
-		if len(result) != 0 && result[resultLen-1].Date == timetricks.Day(gt.Time) {
+		if len(result) != 0 && result[resultLen-1].Date == locale.DayIn(calendar, gt.Time) {
 			// There is already an entry in the result that corresponds to the
 			// same day as the next time we're entering.
 			result[resultLen-1].GoodTimes = append(result[resultLen-1].GoodTimes, gt)
 		} else {
 			// Normal case.
 			result = append(result, PresentationElement{
-				Date:      timetricks.Day(gt.Time),
-				GoodTimes: []meta.GoodTime{gt},
-				TideImage: template.HTML(imgToString(tideimages, gt.Time)),
+				Date:        locale.DayIn(calendar, gt.Time),
+				GoodTimes:   []meta.GoodTime{gt},
+				TideImage:   template.HTML(imgToString(tideimages, gt.Time)),
+				TideSummary: tideimages.Summary(),
 			})
 		}
 
@@ -196,49 +210,348 @@ func goodTimesToPresentationElements(tideimages *visualize.Tidal, goodTimes []me
 	return f(nil, goodTimes)
 }
 
-func goodTimeOptionsFromSession(s *sessions.Session) (meta.Options, *data.User) {
-	opts := meta.Options{}
-
-	id, ok := s.Values[userID]
-	if !ok {
-		return opts, nil
+func bestScore(goodTimes []meta.GoodTime) int {
+	best := 0
+	for _, gt := range goodTimes {
+		if gt.Score > best {
+			best = gt.Score
+		}
 	}
+	return best
+}
 
-	// Note the db lookup can fail here, and that's
+func (srv *Server) goodTimeOptionsFromSession(s *sessions.Session) (meta.Options, *data.User) {
+	// Note the user lookup can fail here, and that's
 	// fine. We'll just use default options.
-	var user data.User
-	if r := db.First(&user, id); r.Error != nil {
-		log.Printf("Failed to find user %v: %v", id, r.Error)
-	} else {
-		// Log the time since we last saw the user.
-		if !user.LastSeen.IsZero() {
-			sinceLastUpdate := time.Since(user.LastSeen)
-			log.Printf("User %d (%q) was last seen %s ago", id, user.Name, sinceLastUpdate)
-		}
-		user.LastSeen = time.Now()
-		db.Save(&user)
+	user, err := srv.loadUser(s)
+	if err != nil {
+		log.Printf("Failed to find user %v: %v", s.Values[userID], err)
+		return meta.Options{}, &data.User{}
+	}
+	if user == nil {
+		return meta.Options{}, nil
+	}
+	srv.touchUser(user)
+	return optionsForUser(user), user
+}
+
+// localeFor returns the locale of the language and clock in the session, if
+// set, or the best language of the request.
+func localeFor(r *http.Request, s *sessions.Session) i18n.Locale {
+	lang, _ := s.Values[sessionLanguage].(string)
+	if lang == "" {
+		lang = i18n.Match(r.Header.Get("Accept-Language"))
+	}
+	locale := i18n.ForLanguage(lang)
+	switch s.Values[sessionClock] {
+	case "12":
+		locale.Hour24 = false
+	case "24":
+		locale.Hour24 = true
 	}
+	return locale
+}
+
+// optionsForUser returns the good time options the user saved.
+func optionsForUser(user *data.User) meta.Options {
+	opts := meta.Options{}
 	opts.LowTideThresh = user.MinTide
 	opts.HighTideThresh = user.MaxTide
+	opts.MinDuration = sessionOption(user.MinDuration)
+	opts.MergeGap = sessionOption(user.MergeGap)
+	if user.Direction != "" {
+		if dir, err := meta.ParseDirection(user.Direction); err != nil {
+			log.Printf("Failed to read direction of user %v: %v", user.ID, err)
+		} else {
+			opts.Direction = &dir
+		}
+	}
+	opts.MaxRate = user.MaxRate
+	if days, err := parseWeekdays(user.Weekdays); err != nil {
+		log.Printf("Failed to read weekdays of user %v: %v", user.ID, err)
+	} else {
+		opts.Days = days
+	}
+	if user.Availability != "" {
+		var avail meta.Availability
+		if err := json.Unmarshal([]byte(user.Availability), &avail); err != nil {
+			log.Printf("Failed to read availability of user %v: %v", user.ID, err)
+		} else {
+			opts.Availability = &avail
+		}
+	}
+	return opts
+}
+
+// ensureFeedToken gives the user a feed token if they do not have one yet.
+func ensureFeedToken(user *data.User) error {
+	if user.FeedToken != "" {
+		return nil
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("failed to make feed token: %w", err)
+	}
+	user.FeedToken = base64.RawURLEncoding.EncodeToString(token)
+	return nil
+}
+
+// maxSessionOption is the longest MinDuration and MergeGap may be. Longer
+// gaps would merge good times across the night.
+const maxSessionOption = 4 * time.Hour
+
+// sessionOption limits a saved MinDuration or MergeGap to maxSessionOption,
+// for users who saved longer ones before there was a limit.
+func sessionOption(d time.Duration) time.Duration {
+	if d > maxSessionOption {
+		return maxSessionOption
+	}
+	return d
+}
+
+// minutesFromForm reads a number of minutes, up to maxSessionOption, from the
+// form. Missing values are zero.
+func minutesFromForm(form url.Values, key string) (time.Duration, error) {
+	s := form.Get(key)
+	if s == "" {
+		return 0, nil
+	}
+	minutes, err := strconv.ParseFloat(s, 64)
+	if err != nil || minutes < 0 || minutes > maxSessionOption.Minutes() {
+		return 0, fmt.Errorf("%s %q is not a number of minutes from 0 to %.0f", key, s, maxSessionOption.Minutes())
+	}
+	return time.Duration(minutes * float64(time.Minute)), nil
+}
+
+// availabilityDay is a row of the availability section of the config page.
+type availabilityDay struct {
+	Key, Name string
+	From, To  string
+	Off       bool
+}
+
+// weekdays are the days of the config page, in order.
+var weekdays = []time.Weekday{
+	time.Monday,
+	time.Tuesday,
+	time.Wednesday,
+	time.Thursday,
+	time.Friday,
+	time.Saturday,
+	time.Sunday,
+}
+
+func weekdayKey(wd time.Weekday) string {
+	return strings.ToLower(wd.String()[:3])
+}
+
+// parseWeekdays reads days of the week by key, separated by commas or spaces.
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, key := range strings.Fields(strings.ReplaceAll(s, ",", " ")) {
+		found := false
+		for _, wd := range weekdays {
+			if weekdayKey(wd) == strings.ToLower(key) {
+				days = append(days, wd)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown day of the week %q", key)
+		}
+	}
+	return days, nil
+}
+
+// weekdayChoice is a day of the week the user may choose to surf on the config
+// page.
+type weekdayChoice struct {
+	Key, Name string
+	Checked   bool
+}
+
+// weekdayChoices describes days for the config page. No days at all is every
+// day.
+func weekdayChoices(days []time.Weekday, locale i18n.Locale) []weekdayChoice {
+	var result []weekdayChoice
+	for _, wd := range weekdays {
+		choice := weekdayChoice{Key: weekdayKey(wd), Name: locale.T(wd.String()), Checked: len(days) == 0}
+		for _, d := range days {
+			if d == wd {
+				choice.Checked = true
+			}
+		}
+		result = append(result, choice)
+	}
+	return result
+}
+
+// tideTrendFromForm reads the direction, max_rate, and weekdays fields of the
+// config page into user.
+func tideTrendFromForm(form url.Values, user *data.User) error {
+	user.Direction = ""
+	if s := form.Get("direction"); s != "" {
+		dir, err := meta.ParseDirection(s)
+		if err != nil {
+			return err
+		}
+		user.Direction = string(dir)
+	}
+	user.MaxRate = nil
+	if s := form.Get("max_rate"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f <= 0 {
+			return fmt.Errorf("max rate %q is not a positive number", s)
+		}
+		user.MaxRate = &f
+	}
+	days, err := parseWeekdays(strings.Join(form["weekdays"], " "))
+	if err != nil {
+		return err
+	}
+	if len(days) == len(weekdays) {
+		// Every day is the same as no days at all.
+		days = nil
+	}
+	var keys []string
+	for _, wd := range days {
+		keys = append(keys, weekdayKey(wd))
+	}
+	user.Weekdays = strings.Join(keys, ",")
+	return nil
+}
+
+// availabilityDays describes avail for the config page. Only the first range
+// of each day is shown.
+func availabilityDays(avail *meta.Availability, locale i18n.Locale) []availabilityDay {
+	var result []availabilityDay
+	for _, wd := range weekdays {
+		day := availabilityDay{Key: weekdayKey(wd), Name: locale.T(wd.String())}
+		if avail != nil {
+			if ranges, ok := avail.Weekly[wd]; ok {
+				if len(ranges) == 0 {
+					day.Off = true
+				} else {
+					day.From, day.To = ranges[0].From.String(), ranges[0].To.String()
+					if ranges[0].To == 24*60 {
+						// Time inputs stop at 23:59; blank is the end of the day.
+						day.To = ""
+					}
+				}
+			}
+		}
+		result = append(result, day)
+	}
+	return result
+}
+
+// maxBlackouts is the most blackout dates a user may have. Without a store,
+// preferences live in a cookie, which browsers limit to 4KB.
+const maxBlackouts = 30
+
+// availabilityFromForm reads the availability section of the config page.
+func availabilityFromForm(form url.Values) (meta.Availability, error) {
+	avail := meta.Availability{Weekly: map[time.Weekday][]meta.ClockRange{}}
+	for _, wd := range weekdays {
+		key := weekdayKey(wd)
+		if form.Get("off_"+key) != "" {
+			avail.Weekly[wd] = []meta.ClockRange{}
+			continue
+		}
+		from, to := form.Get("from_"+key), form.Get("to_"+key)
+		if from == "" && to == "" {
+			continue
+		}
+		r := meta.ClockRange{From: 0, To: 24 * 60}
+		var err error
+		if from != "" {
+			if r.From, err = meta.ParseClock(from); err != nil {
+				return avail, fmt.Errorf("%s: %w", wd, err)
+			}
+		}
+		if to != "" {
+			if r.To, err = meta.ParseClock(to); err != nil {
+				return avail, fmt.Errorf("%s: %w", wd, err)
+			}
+		}
+		if r.To <= r.From {
+			return avail, fmt.Errorf("%s: %s is not before %s", wd, r.From, r.To)
+		}
+		avail.Weekly[wd] = []meta.ClockRange{r}
+	}
+
+	dates := strings.Fields(strings.ReplaceAll(form.Get("blackouts"), ",", " "))
+	if len(dates) > maxBlackouts {
+		return avail, fmt.Errorf("%d blackout dates is more than %d", len(dates), maxBlackouts)
+	}
+	for _, date := range dates {
+		if _, err := time.Parse(meta.BlackoutFmt, date); err != nil {
+			return avail, fmt.Errorf("blackout date %q is not in the form %s", date, meta.BlackoutFmt)
+		}
+		avail.Blackouts = append(avail.Blackouts, date)
+	}
+	return avail, nil
+}
 
-	return opts, &user
+// saveSession saves the session, or tells the client that it could not. Cookie
+// sessions fail to save once they grow too large.
+func saveSession(w http.ResponseWriter, r *http.Request, session *sessions.Session) bool {
+	if err := session.Save(r, w); err != nil {
+		msg := fmt.Sprintf("Failed to save preferences: %v", err)
+		log.Println(msg)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, msg)
+		return false
+	}
+	return true
 }
 
-func makeConfigTideParameters(redirectPrefix string, content embed.FS) http.HandlerFunc {
+func (srv *Server) makeConfigTideParameters(redirectPrefix string, content embed.FS) http.HandlerFunc {
 	configTideTemplate := template.Must(template.ParseFS(content, "static/config_tide.template.html"))
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		session, _ := store.Get(r, sessionName)
+		session, _ := srv.Sessions.Get(r, sessionName)
 		metrics.ObserveUserRequest(session.Values[userID])
 
 		if r.Method == "GET" {
-			session.Save(r, w)
-			opts, user := goodTimeOptionsFromSession(session)
+			if err := session.Save(r, w); err != nil {
+				log.Println("save session err", err)
+			}
+			opts, user := srv.goodTimeOptionsFromSession(session)
+			if srv.Store != nil && user != nil && user.ID != 0 && user.FeedToken == "" {
+				// Users from before feeds get a token the next time they
+				// look at their config.
+				if err := ensureFeedToken(user); err != nil {
+					log.Println(err)
+				} else if err := srv.Store.SaveUser(user); err != nil {
+					log.Printf("Failed to save feed token of user %d: %v", user.ID, err)
+				}
+			}
 			opts.DefaultHighTide = ptr(float64(1))
 			opts.DefaultLowTide = ptr(float64(-1000))
+			direction := ""
+			if opts.Direction != nil {
+				direction = string(*opts.Direction)
+			}
+			var blackouts []string
+			if opts.Availability != nil {
+				blackouts = opts.Availability.Blackouts
+			}
+			locale := localeFor(r, session)
 			if err := configTideTemplate.Execute(w, map[string]any{
-				"Options": opts,
-				"User":    user,
+				"Options":           opts,
+				"User":              user,
+				"Days":              availabilityDays(opts.Availability, locale),
+				"Weekdays":          weekdayChoices(opts.Days, locale),
+				"MaxSessionMinutes": int(maxSessionOption.Minutes()),
+				"Direction":         direction,
+				"Blackouts":         strings.Join(blackouts, " "),
+				"Overview":          session.Values[sessionOverview],
+				"Locale":            locale,
+				"Languages":         i18n.Languages(),
+				"Language":          session.Values[sessionLanguage],
+				"Clock":             session.Values[sessionClock],
 			}); err != nil {
 				log.Printf("Failed to write configTideTemplate: %v", err)
 			}
@@ -259,11 +572,14 @@ func makeConfigTideParameters(redirectPrefix string, content embed.FS) http.Hand
 			return
 		}
 
-		var user data.User
-		if id, ok := session.Values[userID].(uint); ok {
-			// Read-modify-write if the user provided an ID.
-			// Otherwise, one will be generated with db.Save later.
-			db.First(&user, id)
+		// Read-modify-write if the session has a user.
+		// Otherwise, one will be made when it is saved later.
+		user, err := srv.loadUser(session)
+		if err != nil {
+			log.Printf("Failed to find user %v: %v", session.Values[userID], err)
+		}
+		if user == nil {
+			user = &data.User{}
 		}
 		if f, err := strconv.ParseFloat(r.PostForm.Get("min_tide"), 64); err == nil {
 			user.MinTide = &f
@@ -275,6 +591,36 @@ func makeConfigTideParameters(redirectPrefix string, content embed.FS) http.Hand
 		} else {
 			user.MaxTide = nil
 		}
+		if user.MinDuration, err = minutesFromForm(r.PostForm, "min_duration"); err == nil {
+			user.MergeGap, err = minutesFromForm(r.PostForm, "merge_gap")
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Failed to read session lengths: %v", err)
+			log.Println(msg)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, msg)
+			return
+		}
+		if err := tideTrendFromForm(r.PostForm, user); err != nil {
+			msg := fmt.Sprintf("Failed to read tide preferences: %v", err)
+			log.Println(msg)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, msg)
+			return
+		}
+		avail, err := availabilityFromForm(r.PostForm)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to read availability: %v", err)
+			log.Println(msg)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, msg)
+			return
+		}
+		if blob, err := json.Marshal(avail); err != nil {
+			log.Printf("Failed to encode availability: %v", err)
+		} else {
+			user.Availability = string(blob)
+		}
 
 		// Parse the birthday field.
 		birthdayStr := r.PostForm.Get("birthday")
@@ -289,25 +635,50 @@ func makeConfigTideParameters(redirectPrefix string, content embed.FS) http.Hand
 
 		// Log the time since the last update.
 		if user.UpdatedAt.IsZero() {
-			log.Printf("User %d (%q) has never been updated", id, user.Name)
+			log.Printf("User %d (%q) has never been updated", user.ID, user.Name)
 		} else {
-			sinceLastUpdate := time.Now().Sub(user.UpdatedAt)
-			log.Printf("User %d (%q) was last updated %s ago", id, user.Name, sinceLastUpdate)
+			sinceLastUpdate := srv.Clock.Now().Sub(user.UpdatedAt)
+			log.Printf("User %d (%q) was last updated %s ago", user.ID, user.Name, sinceLastUpdate)
+		}
+
+		// Feeds find users in the store, so only stored users get a token.
+		if srv.Store != nil {
+			if err := ensureFeedToken(user); err != nil {
+				log.Println(err)
+			}
 		}
 
 		// Set the LastSeen column to the current time.
-		user.LastSeen = time.Now()
+		user.LastSeen = srv.Clock.Now()
 		user.Name = r.PostForm.Get("name")
-		if tx := db.Save(&user); tx.Error != nil {
-			msg := fmt.Sprintf("Failed to save preferences: %v", tx.Error)
+		if err := srv.saveUser(session, user); err != nil {
+			msg := fmt.Sprintf("Failed to save preferences: %v", err)
 			log.Println(msg)
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintf(w, msg)
 			return
 		}
-		session.Values[userID] = user.ID
+		switch overview := r.PostForm.Get("overview"); overview {
+		case "", "strip", "heatmap":
+			session.Values[sessionOverview] = overview
+		default:
+			log.Printf("Ignoring unknown overview %q", overview)
+		}
+		if lang := r.PostForm.Get("language"); lang == "" || i18n.ForLanguage(lang).Lang == lang {
+			session.Values[sessionLanguage] = lang
+		} else {
+			log.Printf("Ignoring unknown language %q", lang)
+		}
+		switch clock := r.PostForm.Get("clock"); clock {
+		case "", "12", "24":
+			session.Values[sessionClock] = clock
+		default:
+			log.Printf("Ignoring unknown clock %q", clock)
+		}
 		session.Values["name"] = r.PostForm.Get("name")
-		session.Save(r, w)
+		if !saveSession(w, r, session) {
+			return
+		}
 
 		// Redirect to whatever they saw last, or the index.
 		referredFrom, ok := session.Values[sessionLastViewed].(string)
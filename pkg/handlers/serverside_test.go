@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/sessions"
+	"github.com/spencer-p/surfdash/pkg/data"
+	"github.com/spencer-p/surfdash/pkg/meta"
+)
+
+func TestTideTrendPreferences(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		form    url.Values
+		want    meta.Options
+		wantErr bool
+	}{{
+		name: "nothing",
+		form: url.Values{},
+		want: meta.Options{},
+	}, {
+		name: "everything",
+		form: url.Values{
+			"direction": {"rising"},
+			"max_rate":  {"1.5"},
+			"weekdays":  {"sat", "sun"},
+		},
+		want: meta.Options{
+			Direction: ptr(meta.Rising),
+			MaxRate:   ptr(1.5),
+			Days:      []time.Weekday{time.Saturday, time.Sunday},
+		},
+	}, {
+		name: "every day",
+		form: url.Values{"weekdays": {"mon", "tue", "wed", "thu", "fri", "sat", "sun"}},
+		want: meta.Options{},
+	}, {
+		name:    "unknown direction",
+		form:    url.Values{"direction": {"sideways"}},
+		wantErr: true,
+	}, {
+		name:    "negative rate",
+		form:    url.Values{"max_rate": {"-1"}},
+		wantErr: true,
+	}, {
+		name:    "unknown day",
+		form:    url.Values{"weekdays": {"someday"}},
+		wantErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &data.User{Direction: "falling", MaxRate: ptr(2.0), Weekdays: "mon"}
+			err := tideTrendFromForm(tc.form, user)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("tideTrendFromForm succeeded, wanted an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tideTrendFromForm failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, optionsForUser(user)); diff != "" {
+				t.Errorf("options (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestMinutesFromForm(t *testing.T) {
+	for _, tc := range []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"45", 45 * time.Minute, false},
+		{"1.5", 90 * time.Second, false},
+		{"240", 4 * time.Hour, false},
+		{"241", 0, true},
+		{"100000", 0, true},
+		{"-5", 0, true},
+		{"soon", 0, true},
+	} {
+		got, err := minutesFromForm(url.Values{"merge_gap": {tc.value}}, "merge_gap")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("minutesFromForm(%q) error = %v, wanted error %v", tc.value, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("minutesFromForm(%q) = %v, wanted %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestOptionsForUserLimitsSessions(t *testing.T) {
+	opts := optionsForUser(&data.User{MinDuration: 30 * time.Minute, MergeGap: 12 * time.Hour})
+	if opts.MinDuration != 30*time.Minute {
+		t.Errorf("got MinDuration %v, wanted 30m", opts.MinDuration)
+	}
+	if opts.MergeGap != maxSessionOption {
+		t.Errorf("got MergeGap %v, wanted the limit %v", opts.MergeGap, maxSessionOption)
+	}
+}
+
+// fullAvailability returns a form with the most availability a user may have.
+func fullAvailability() url.Values {
+	form := url.Values{}
+	var dates []string
+	for i := 0; i < maxBlackouts; i++ {
+		dates = append(dates, time.Date(2021, time.June, 1+i, 0, 0, 0, 0, time.UTC).Format(meta.BlackoutFmt))
+	}
+	form.Set("blackouts", strings.Join(dates, " "))
+	for _, wd := range weekdays {
+		key := strings.ToLower(wd.String()[:3])
+		form.Set("from_"+key, "05:30")
+		form.Set("to_"+key, "19:45")
+	}
+	return form
+}
+
+func TestAvailabilityBlackoutLimit(t *testing.T) {
+	form := fullAvailability()
+	if _, err := availabilityFromForm(form); err != nil {
+		t.Fatalf("availabilityFromForm of %d blackouts failed: %v", maxBlackouts, err)
+	}
+	form.Set("blackouts", form.Get("blackouts")+" 2022-01-01")
+	if _, err := availabilityFromForm(form); err == nil {
+		t.Errorf("availabilityFromForm of %d blackouts succeeded, wanted an error", maxBlackouts+1)
+	}
+}
+
+func TestSaveSession(t *testing.T) {
+	srv := testServer(time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC))
+	avail, err := availabilityFromForm(fullAvailability())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := json.Marshal(avail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &data.User{
+		Name:         strings.Repeat("n", 64),
+		MinTide:      ptr(0.5),
+		MaxTide:      ptr(2.5),
+		MaxRate:      ptr(1.5),
+		Direction:    "falling",
+		Weekdays:     "mon,tue,wed,thu,fri,sat,sun",
+		Availability: string(blob),
+		MinDuration:  maxSessionOption,
+		MergeGap:     maxSessionOption,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		user     *data.User
+		wantSave bool
+	}{
+		{"the most preferences", user, true},
+		{"too large for a cookie", &data.User{Name: strings.Repeat("n", 5000)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			session := sessions.NewSession(srv.Sessions, sessionName)
+			session.Values[sessionLastViewed] = "/?start=2021-06-01T00:00:00-07:00"
+			session.Values[sessionLanguage] = "es"
+			session.Values["name"] = tc.user.Name
+			if err := srv.saveUser(session, tc.user); err != nil {
+				t.Fatalf("saveUser failed: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			saved := saveSession(w, httptest.NewRequest(http.MethodPost, "/config", nil), session)
+			if saved != tc.wantSave {
+				t.Fatalf("saveSession = %v, wanted %v", saved, tc.wantSave)
+			}
+			if saved {
+				if w.Header().Get("Set-Cookie") == "" {
+					t.Errorf("saveSession set no cookie")
+				}
+				return
+			}
+			if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Failed to save preferences") {
+				t.Errorf("got code %d and %q, wanted 500 and why", w.Code, w.Body)
+			}
+			if got := w.Header().Get("Set-Cookie"); got != "" {
+				t.Errorf("saveSession set cookie %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package i18n translates and formats text for readers of other languages.
+package i18n
@@ -0,0 +1,120 @@
+package i18n
+
+var spanish = catalog{
+	name:     "Español",
+	hour24:   true,
+	dateFmt:  "02/01",
+	longDate: "%[1]s, %[3]d de %[2]s",
+	messages: map[string]string{
+		// Days and months.
+		"Today":     "Hoy",
+		"Tomorrow":  "Mañana",
+		"Sunday":    "Domingo",
+		"Monday":    "Lunes",
+		"Tuesday":   "Martes",
+		"Wednesday": "Miércoles",
+		"Thursday":  "Jueves",
+		"Friday":    "Viernes",
+		"Saturday":  "Sábado",
+		"Sun":       "Dom",
+		"Mon":       "Lun",
+		"Tue":       "Mar",
+		"Wed":       "Mié",
+		"Thu":       "Jue",
+		"Fri":       "Vie",
+		"Sat":       "Sáb",
+		"January":   "enero",
+		"February":  "febrero",
+		"March":     "marzo",
+		"April":     "abril",
+		"May":       "mayo",
+		"June":      "junio",
+		"July":      "julio",
+		"August":    "agosto",
+		"September": "septiembre",
+		"October":   "octubre",
+		"November":  "noviembre",
+		"December":  "diciembre",
+
+		// Good times and their reasons.
+		"%s at %s%s":                         "%s a las %s%s",
+		" until %s":                          " hasta las %s",
+		" and ":                              " y ",
+		"No good times found.":               "No hay buenos momentos.",
+		"tide is low at %.2fft":              "la marea está baja a %.2fft",
+		"tide is %.1fft at %s":               "la marea está a %.1fft a las %s",
+		"%.0f minutes after sunset":          "%.0f minutos después del atardecer",
+		"only %.0f minutes before sunrise":   "solo %.0f minutos antes del amanecer",
+		"tide is slack":                      "la marea está quieta",
+		"tide is rising at up to %.1fft/hr":  "la marea sube hasta %.1fft/h",
+		"tide is falling at up to %.1fft/hr": "la marea baja hasta %.1fft/h",
+
+		// Tide charts.
+		"High tide":         "Marea alta",
+		"Low tide":          "Marea baja",
+		"Sunrise":           "Amanecer",
+		"Sunset":            "Atardecer",
+		"Good time":         "Buen momento",
+		"%s until %s":       "%s hasta las %s",
+		"%s at %s":          "%s a las %s",
+		"%s from %s":        "%s desde las %s",
+		"Tide chart for %s": "Gráfico de mareas del %s",
+		"No tide data.":     "Sin datos de marea.",
+
+		// Overview charts.
+		"Tide chart for %d days from %s":         "Gráfico de mareas de %d días desde el %s",
+		"Good times by hour for %d days from %s": "Buenos momentos por hora de %d días desde el %s",
+		"%s at %s: %.0f minutes":                 "%s a las %s: %.0f minutos",
+
+		// The index page.
+		"Tide table":            "Tabla de mareas",
+		"Time":                  "Hora",
+		"Event":                 "Evento",
+		"Height":                "Altura",
+		"score out of 100":      "puntuación sobre 100",
+		"Good luck out there 😬": "Buena suerte ahí fuera 😬",
+		"prev":                  "anterior",
+		"today":                 "hoy",
+		"next":                  "siguiente",
+		"fork me on":            "bifúrcame en",
+		"config":                "ajustes",
+
+		// The config page.
+		"config surfdash":                       "ajustes de surfdash",
+		"Lower tide boundary: ":                 "Marea mínima: ",
+		"Higher tide boundary: ":                "Marea máxima: ",
+		"Shortest session (minutes): ":          "Sesión más corta (minutos): ",
+		"Merge sessions this close (minutes): ": "Unir sesiones así de cercanas (minutos): ",
+		"Fastest tide change (ft/hr): ":         "Cambio de marea más rápido (ft/h): ",
+		"Tide direction: ":                      "Dirección de la marea: ",
+		"any":                                   "cualquiera",
+		"rising":                                "subiendo",
+		"falling":                               "bajando",
+		"slack":                                 "quieta",
+		"Days to surf: ":                        "Días para surfear: ",
+		"Availability":                          "Disponibilidad",
+		"Leave a day blank to surf any time that day.": "Deja un día en blanco para surfear a cualquier hora ese día.",
+		"%s from":                      "%s desde",
+		"%s to":                        "%s hasta",
+		"to":                           "a",
+		"busy":                         "ocupado",
+		"Blackout dates: ":             "Fechas bloqueadas: ",
+		"Overview chart: ":             "Gráfico general: ",
+		"none":                         "ninguno",
+		"tide strip":                   "franja de mareas",
+		"weekly heatmap":               "mapa de calor semanal",
+		"Language: ":                   "Idioma: ",
+		"automatic":                    "automático",
+		"Clock: ":                      "Reloj: ",
+		"12 hour":                      "12 horas",
+		"24 hour":                      "24 horas",
+		"Calendar: ":                   "Calendario: ",
+		"subscribe to your good times": "suscríbete a tus buenos momentos",
+		"Feed reader: ":                "Lector de feeds: ",
+		"follow your good times":       "sigue tus buenos momentos",
+		"Name: ":                       "Nombre: ",
+		"Your name here":               "Tu nombre",
+		"(Optional) Birthday: ":        "(Opcional) Cumpleaños: ",
+		"Submit":                       "Guardar",
+	},
+}
@@ -0,0 +1,152 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/timetricks"
+)
+
+// catalog holds the messages of a language, keyed by their English text.
+type catalog struct {
+	// name is the name of the language in itself.
+	name string
+	// hour24 is true if the language usually uses a 24 hour clock.
+	hour24 bool
+	// dateFmt is the short form of a date.
+	dateFmt string
+	// longDate formats the weekday, month, and day of the month, in that
+	// order.
+	longDate string
+	messages map[string]string
+}
+
+var catalogs = map[string]catalog{
+	"en": {
+		name:     "English",
+		dateFmt:  "01/02",
+		longDate: "%[1]s, %[2]s %[3]d",
+	},
+	"es": spanish,
+}
+
+// Locale formats text for a reader.
+type Locale struct {
+	// Lang is a language with a catalog, like "en" or "es". Unknown
+	// languages are English.
+	Lang string
+	// Hour24 shows times on a 24 hour clock.
+	Hour24 bool
+}
+
+// English is the default locale, which the messages are written in.
+var English = Locale{Lang: "en"}
+
+// Language is a language with a catalog.
+type Language struct {
+	Tag, Name string
+}
+
+// Languages lists the languages with catalogs, in order of their tags.
+func Languages() []Language {
+	var result []Language
+	for tag, c := range catalogs {
+		result = append(result, Language{Tag: tag, Name: c.name})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+// ForLanguage returns the locale of the language with its usual clock.
+func ForLanguage(lang string) Locale {
+	c, ok := catalogs[lang]
+	if !ok {
+		return English
+	}
+	return Locale{Lang: lang, Hour24: c.hour24}
+}
+
+// Match returns the language of a catalog that best suits an Accept-Language
+// header, or "" if none do.
+func Match(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := catalogs[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+func (l Locale) catalog() catalog {
+	if c, ok := catalogs[l.Lang]; ok {
+		return c
+	}
+	return catalogs["en"]
+}
+
+// T translates the message and formats it with args like fmt.Sprintf.
+// Messages without a translation are left in English.
+func (l Locale) T(msg string, args ...any) string {
+	if translated, ok := l.catalog().messages[msg]; ok {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Time formats the time of day.
+func (l Locale) Time(t time.Time) string {
+	if l.Hour24 {
+		return t.Format("15:04")
+	}
+	return t.Format("3:04 PM")
+}
+
+// Hour formats the hour of t briefly, for labels.
+func (l Locale) Hour(t time.Time) string {
+	if l.Hour24 {
+		return t.Format("15")
+	}
+	return t.Format("3PM")
+}
+
+// Date formats the month and day briefly.
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.catalog().dateFmt)
+}
+
+// LongDate formats the weekday, month, and day of the month.
+func (l Locale) LongDate(t time.Time) string {
+	return fmt.Sprintf(l.catalog().longDate, l.T(t.Weekday().String()), l.T(t.Month().String()), t.Day())
+}
+
+// DayIn names the day like timetricks.Day, reckoning today by the calendar c.
+func (l Locale) DayIn(c timetricks.Calendar, t time.Time) string {
+	names := timetricks.DayNames{
+		Today:    l.T("Today"),
+		Tomorrow: l.T("Tomorrow"),
+		DateFmt:  l.catalog().dateFmt,
+	}
+	for wd := range names.Weekdays {
+		names.Weekdays[wd] = l.T(time.Weekday(wd).String())
+	}
+	return c.DayWith(t, names)
+}
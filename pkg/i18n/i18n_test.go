@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spencer-p/surfdash/pkg/timetricks"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"es", "es"},
+		{"es-MX,es;q=0.9", "es"},
+		{"en-US,en;q=0.9,es;q=0.8", "en"},
+		{"de-DE,es;q=0.5,en;q=0.7", "en"},
+		{"fr, es;q=0.1", "es"},
+		{"de", ""},
+		{"es;q=bogus", ""},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			if got := Match(tc.header); got != tc.want {
+				t.Errorf("Match(%q) = %q, wanted %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLocale(t *testing.T) {
+	when := time.Date(2021, time.April, 3, 16, 5, 0, 0, time.UTC)
+	es := ForLanguage("es")
+	es12 := es
+	es12.Hour24 = false
+	cal := timetricks.Calendar{Clock: timetricks.Fixed(when), Location: time.UTC}
+	for _, tc := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"english time", English.Time(when), "4:05 PM"},
+		{"english hour", English.Hour(when), "4PM"},
+		{"english date", English.Date(when), "04/03"},
+		{"english long date", English.LongDate(when), "Saturday, April 3"},
+		{"english message", English.T("tide is %.1fft at %s", 0.5, "4:05 PM"), "tide is 0.5ft at 4:05 PM"},
+		{"spanish time", es.Time(when), "16:05"},
+		{"spanish 12 hour time", es12.Time(when), "4:05 PM"},
+		{"spanish hour", es.Hour(when), "16"},
+		{"spanish date", es.Date(when), "03/04"},
+		{"spanish long date", es.LongDate(when), "Sábado, 3 de abril"},
+		{"spanish message", es.T("tide is %.1fft at %s", 0.5, "16:05"), "la marea está a 0.5ft a las 16:05"},
+		{"spanish today", es.DayIn(cal, when), "Hoy"},
+		{"spanish weekday", es.DayIn(cal, when.AddDate(0, 0, 2)), "Lunes"},
+		{"untranslated message", es.T("surfdash"), "surfdash"},
+		{"unknown language", ForLanguage("xx").T("Today"), "Today"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %q, wanted %q", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+var verb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// verbs returns the formatting verbs of msg without their argument indexes.
+func verbs(msg string) []string {
+	var result []string
+	for _, m := range verb.FindAllStringSubmatch(msg, -1) {
+		v := m[0]
+		if m[1] != "" {
+			v = "%" + v[len(m[1])+1:]
+		}
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestCatalogsKeepVerbs(t *testing.T) {
+	for lang, c := range catalogs {
+		for msg, translated := range c.messages {
+			if diff := cmp.Diff(verbs(msg), verbs(translated)); diff != "" {
+				t.Errorf("%s translation of %q has different verbs (-want,+got):\n%s", lang, msg, diff)
+			}
+		}
+	}
+}
@@ -0,0 +1,2 @@
+// Package ical writes calendars in the iCalendar format of RFC 5545.
+package ical
@@ -0,0 +1,110 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// utcFmt is the form of a date and time in UTC.
+	utcFmt = "20060102T150405Z"
+
+	// lineLen is the longest line allowed, in octets, not counting the line
+	// break.
+	lineLen = 75
+)
+
+// Calendar is a collection of events.
+type Calendar struct {
+	// ProdID names the product that made the calendar.
+	ProdID string
+	// Name is shown by clients that support it. Optional.
+	Name   string
+	Events []Event
+}
+
+// Event is a VEVENT.
+type Event struct {
+	// UID identifies the event across versions of the calendar. Clients
+	// update events with the same UID instead of adding new ones.
+	UID         string
+	Start, End  time.Time
+	Summary     string
+	Description string
+	Location    string
+	// Sequence counts the revisions of the event. Clients replace an event
+	// with one of a higher sequence.
+	Sequence int
+	// LastModified is when the event last changed. Optional.
+	LastModified time.Time
+}
+
+// Encode writes the calendar. Every event is stamped with now.
+func (c *Calendar) Encode(w io.Writer, now time.Time) error {
+	b := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(b, name+":"+value)
+	}
+	text := func(name, value string) {
+		if value != "" {
+			line(name, escape(value))
+		}
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	text("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	text("X-WR-CALNAME", c.Name)
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		text("UID", e.UID)
+		line("DTSTAMP", now.UTC().Format(utcFmt))
+		line("DTSTART", e.Start.UTC().Format(utcFmt))
+		line("DTEND", e.End.UTC().Format(utcFmt))
+		if e.Sequence > 0 {
+			line("SEQUENCE", strconv.Itoa(e.Sequence))
+		}
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", e.LastModified.UTC().Format(utcFmt))
+		}
+		text("SUMMARY", e.Summary)
+		text("DESCRIPTION", e.Description)
+		text("LOCATION", e.Location)
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Flush()
+}
+
+// escape escapes the special characters of a text value.
+var escape = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+).Replace
+
+// writeFolded writes a content line, breaking it into lines of at most lineLen
+// octets. Lines are only broken between characters.
+func writeFolded(b *bufio.Writer, s string) {
+	limit := lineLen
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continued lines start with a space.
+		limit = lineLen - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
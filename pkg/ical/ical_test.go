@@ -0,0 +1,110 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var now = time.Date(2021, time.April, 3, 12, 0, 0, 0, time.UTC)
+
+func TestEncode(t *testing.T) {
+	pacific := time.FixedZone("PDT", -7*60*60)
+	cal := Calendar{
+		ProdID: "-//surfdash//EN",
+		Name:   "Good times",
+		Events: []Event{{
+			UID:         "20210403-0@example.com",
+			Start:       time.Date(2021, time.April, 3, 6, 30, 0, 0, pacific),
+			End:         time.Date(2021, time.April, 3, 8, 0, 0, 0, pacific),
+			Summary:     "Good time",
+			Description: "tide is low, and rising; then\nslack",
+		}, {
+			UID:          "20210404-0@example.com",
+			Start:        time.Date(2021, time.April, 4, 7, 0, 0, 0, pacific),
+			End:          time.Date(2021, time.April, 4, 9, 0, 0, 0, pacific),
+			Summary:      "Good time",
+			Sequence:     2,
+			LastModified: time.Date(2021, time.April, 3, 4, 0, 0, 0, pacific),
+		}},
+	}
+	var b bytes.Buffer
+	if err := cal.Encode(&b, now); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//surfdash//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Good times",
+		"BEGIN:VEVENT",
+		"UID:20210403-0@example.com",
+		"DTSTAMP:20210403T120000Z",
+		"DTSTART:20210403T133000Z",
+		"DTEND:20210403T150000Z",
+		"SUMMARY:Good time",
+		`DESCRIPTION:tide is low\, and rising\; then\nslack`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:20210404-0@example.com",
+		"DTSTAMP:20210403T120000Z",
+		"DTSTART:20210404T140000Z",
+		"DTEND:20210404T160000Z",
+		"SEQUENCE:2",
+		"LAST-MODIFIED:20210403T110000Z",
+		"SUMMARY:Good time",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("Encode() (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want []string
+	}{{
+		name: "short",
+		in:   "SUMMARY:Good time",
+		want: []string{"SUMMARY:Good time"},
+	}, {
+		name: "exactly the limit",
+		in:   strings.Repeat("a", 75),
+		want: []string{strings.Repeat("a", 75)},
+	}, {
+		name: "long",
+		in:   strings.Repeat("a", 200),
+		want: []string{
+			strings.Repeat("a", 75),
+			" " + strings.Repeat("a", 74),
+			" " + strings.Repeat("a", 51),
+		},
+	}, {
+		name: "does not split characters",
+		in:   strings.Repeat("a", 74) + "é",
+		want: []string{
+			strings.Repeat("a", 74),
+			" é",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			w := bufio.NewWriter(&b)
+			writeFolded(w, tc.in)
+			w.Flush()
+			got := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("writeFolded(%q) (-want,+got):\n%s", tc.in, diff)
+			}
+		})
+	}
+}
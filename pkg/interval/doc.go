@@ -0,0 +1,2 @@
+// Package interval implements set algebra over ranges of time.
+package interval
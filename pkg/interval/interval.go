@@ -0,0 +1,157 @@
+package interval
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is the half open range of time [Start, End).
+type Interval struct {
+	Start, End time.Time
+}
+
+// Empty returns true if the interval contains no time.
+func (i Interval) Empty() bool {
+	return !i.Start.Before(i.End)
+}
+
+// Duration returns the length of the interval.
+func (i Interval) Duration() time.Duration {
+	if i.Empty() {
+		return 0
+	}
+	return i.End.Sub(i.Start)
+}
+
+// Contains returns true if t is within the interval.
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Set is a sorted list of disjoint, non-empty intervals. Use New to build one
+// from arbitrary intervals.
+type Set []Interval
+
+// New returns the union of the given intervals as a Set.
+func New(intervals ...Interval) Set {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, i := range intervals {
+		if !i.Empty() {
+			sorted = append(sorted, i)
+		}
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Start.Before(sorted[b].Start)
+	})
+
+	result := Set{}
+	for _, i := range sorted {
+		last := len(result) - 1
+		if last >= 0 && !i.Start.After(result[last].End) {
+			// Overlapping or touching; extend the last interval.
+			if i.End.After(result[last].End) {
+				result[last].End = i.End
+			}
+			continue
+		}
+		result = append(result, i)
+	}
+	return result
+}
+
+// Duration returns the total time covered by the set.
+func (s Set) Duration() time.Duration {
+	var total time.Duration
+	for _, i := range s {
+		total += i.Duration()
+	}
+	return total
+}
+
+// Contains returns true if t is within any interval of the set.
+func (s Set) Contains(t time.Time) bool {
+	// Find the first interval that ends after t. It is the only one that could
+	// contain t.
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].End.After(t)
+	})
+	return i < len(s) && s[i].Contains(t)
+}
+
+// Covers returns true if every time in iv is within the set.
+func (s Set) Covers(iv Interval) bool {
+	if iv.Empty() {
+		return true
+	}
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].End.After(iv.Start)
+	})
+	return i < len(s) && !s[i].Start.After(iv.Start) && !s[i].End.Before(iv.End)
+}
+
+// Union returns the set of times in either s or o.
+func (s Set) Union(o Set) Set {
+	all := make([]Interval, 0, len(s)+len(o))
+	all = append(all, s...)
+	all = append(all, o...)
+	return New(all...)
+}
+
+// Intersect returns the set of times in both s and o.
+func (s Set) Intersect(o Set) Set {
+	result := Set{}
+	i, j := 0, 0
+	for i < len(s) && j < len(o) {
+		overlap := Interval{
+			Start: latest(s[i].Start, o[j].Start),
+			End:   earliest(s[i].End, o[j].End),
+		}
+		if !overlap.Empty() {
+			result = append(result, overlap)
+		}
+		// Advance whichever interval ends first; it cannot overlap anything
+		// else in the other set.
+		if s[i].End.Before(o[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// Difference returns the set of times in s that are not in o.
+func (s Set) Difference(o Set) Set {
+	result := Set{}
+	j := 0
+	for _, cur := range s {
+		// Skip intervals of o that end before cur begins.
+		for j < len(o) && !o[j].End.After(cur.Start) {
+			j++
+		}
+		for k := j; k < len(o) && o[k].Start.Before(cur.End); k++ {
+			if o[k].Start.After(cur.Start) {
+				result = append(result, Interval{cur.Start, o[k].Start})
+			}
+			cur.Start = latest(cur.Start, o[k].End)
+		}
+		if !cur.Empty() {
+			result = append(result, cur)
+		}
+	}
+	return result
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
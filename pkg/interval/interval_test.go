@@ -0,0 +1,163 @@
+package interval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var epoch = time.Date(2021, time.April, 3, 0, 0, 0, 0, time.UTC)
+
+// hours builds an interval from hour offsets to keep the tables short.
+func hours(start, end int) Interval {
+	return Interval{
+		Start: epoch.Add(time.Duration(start) * time.Hour),
+		End:   epoch.Add(time.Duration(end) * time.Hour),
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []Interval
+		want Set
+	}{{
+		name: "nothing",
+		in:   nil,
+		want: Set{},
+	}, {
+		name: "drops empty",
+		in:   []Interval{hours(3, 3), hours(5, 4)},
+		want: Set{},
+	}, {
+		name: "sorts",
+		in:   []Interval{hours(5, 6), hours(1, 2)},
+		want: Set{hours(1, 2), hours(5, 6)},
+	}, {
+		name: "merges overlapping and touching",
+		in:   []Interval{hours(1, 3), hours(2, 4), hours(4, 5), hours(7, 8)},
+		want: Set{hours(1, 5), hours(7, 8)},
+	}, {
+		name: "swallows contained",
+		in:   []Interval{hours(1, 10), hours(2, 3)},
+		want: Set{hours(1, 10)},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, New(tc.in...)); diff != "" {
+				t.Errorf("New (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAlgebra(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		a, b                  Set
+		union, inter, aMinusB Set
+	}{{
+		name:    "empty",
+		a:       Set{},
+		b:       New(hours(1, 2)),
+		union:   Set{hours(1, 2)},
+		inter:   Set{},
+		aMinusB: Set{},
+	}, {
+		name:    "disjoint",
+		a:       New(hours(1, 2)),
+		b:       New(hours(3, 4)),
+		union:   Set{hours(1, 2), hours(3, 4)},
+		inter:   Set{},
+		aMinusB: Set{hours(1, 2)},
+	}, {
+		name:    "touching",
+		a:       New(hours(1, 2)),
+		b:       New(hours(2, 3)),
+		union:   Set{hours(1, 3)},
+		inter:   Set{},
+		aMinusB: Set{hours(1, 2)},
+	}, {
+		name:    "overlapping",
+		a:       New(hours(1, 4)),
+		b:       New(hours(3, 6)),
+		union:   Set{hours(1, 6)},
+		inter:   Set{hours(3, 4)},
+		aMinusB: Set{hours(1, 3)},
+	}, {
+		name:    "hole punched",
+		a:       New(hours(0, 10)),
+		b:       New(hours(2, 3), hours(5, 6)),
+		union:   Set{hours(0, 10)},
+		inter:   Set{hours(2, 3), hours(5, 6)},
+		aMinusB: Set{hours(0, 2), hours(3, 5), hours(6, 10)},
+	}, {
+		name:    "spanning several",
+		a:       New(hours(0, 2), hours(4, 6), hours(8, 10)),
+		b:       New(hours(1, 9)),
+		union:   Set{hours(0, 10)},
+		inter:   Set{hours(1, 2), hours(4, 6), hours(8, 9)},
+		aMinusB: Set{hours(0, 1), hours(9, 10)},
+	}, {
+		name:    "identical",
+		a:       New(hours(1, 2)),
+		b:       New(hours(1, 2)),
+		union:   Set{hours(1, 2)},
+		inter:   Set{hours(1, 2)},
+		aMinusB: Set{},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.union, tc.a.Union(tc.b)); diff != "" {
+				t.Errorf("Union (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.inter, tc.a.Intersect(tc.b)); diff != "" {
+				t.Errorf("Intersect (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.inter, tc.b.Intersect(tc.a)); diff != "" {
+				t.Errorf("Intersect is not commutative (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.aMinusB, tc.a.Difference(tc.b)); diff != "" {
+				t.Errorf("Difference (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	s := New(hours(1, 2), hours(4, 5))
+	for _, tc := range []struct {
+		hour float64
+		want bool
+	}{
+		{0, false},
+		{1, true},
+		{1.5, true},
+		{2, false},
+		{4.5, true},
+		{5, false},
+		{6, false},
+	} {
+		at := epoch.Add(time.Duration(tc.hour * float64(time.Hour)))
+		if got := s.Contains(at); got != tc.want {
+			t.Errorf("Contains(%v) = %t, wanted %t", tc.hour, got, tc.want)
+		}
+	}
+	for _, tc := range []struct {
+		iv   Interval
+		want bool
+	}{
+		{hours(1, 2), true},
+		{hours(4, 5), true},
+		{hours(1, 5), false},
+		{hours(0, 2), false},
+		{hours(3, 3), true},
+		{hours(6, 7), false},
+	} {
+		if got := s.Covers(tc.iv); got != tc.want {
+			t.Errorf("Covers(%v) = %t, wanted %t", tc.iv, got, tc.want)
+		}
+	}
+	if got, want := s.Duration(), 2*time.Hour; got != want {
+		t.Errorf("Duration() = %v, wanted %v", got, want)
+	}
+}
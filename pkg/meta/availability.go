@@ -0,0 +1,114 @@
+package meta
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+)
+
+// BlackoutFmt is the format of blackout dates.
+const BlackoutFmt = "2006-01-02"
+
+// Clock is a time of day in minutes after midnight. 24:00 is the end of the
+// day.
+type Clock int
+
+// ParseClock parses a time of day in the form 15:04.
+func ParseClock(s string) (Clock, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("time of day %q not in the form 15:04", s)
+	}
+	c := Clock(h*60 + m)
+	if h < 0 || m < 0 || m >= 60 || c > 24*60 {
+		return 0, fmt.Errorf("time of day %q out of range", s)
+	}
+	return c, nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c/60, c%60)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(text []byte) error {
+	parsed, err := ParseClock(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// on returns the time c on the date of day, in the location of day.
+func (c Clock) on(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, int(c)/60, int(c)%60, 0, 0, day.Location())
+}
+
+// ClockRange is the times of day from From until To.
+type ClockRange struct {
+	From Clock `json:"from"`
+	To   Clock `json:"to"`
+}
+
+// Availability is a Rule that passes when someone is available to surf. It
+// does not give reasons.
+type Availability struct {
+	// Weekly lists the times available on each day of the week. Days that
+	// are missing are available all day, and days with no times are not
+	// available at all.
+	Weekly map[time.Weekday][]ClockRange `json:"weekly,omitempty"`
+
+	// Blackouts are dates, in BlackoutFmt, that are not available at all.
+	Blackouts []string `json:"blackouts,omitempty"`
+}
+
+func (a Availability) Allowed(c Conditions) interval.Set {
+	domain := c.domain()
+	if len(domain) == 0 {
+		return domain
+	}
+	return a.within(domain[0].Start, domain[0].End)
+}
+
+func (a Availability) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	return a.within(w.Start, w.End).Covers(w), nil
+}
+
+// within returns the available times on the days from start to end, in the
+// location of start.
+func (a Availability) within(start, end time.Time) interval.Set {
+	var available []interval.Interval
+	y, m, d := start.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, start.Location()); !day.After(end); day = day.AddDate(0, 0, 1) {
+		if a.blackout(day) {
+			continue
+		}
+		ranges, ok := a.Weekly[day.Weekday()]
+		if !ok {
+			ranges = []ClockRange{{From: 0, To: 24 * 60}}
+		}
+		for _, r := range ranges {
+			available = append(available, interval.Interval{
+				Start: r.From.on(day),
+				End:   r.To.on(day),
+			})
+		}
+	}
+	return interval.New(available...)
+}
+
+func (a Availability) blackout(day time.Time) bool {
+	date := day.Format(BlackoutFmt)
+	for _, b := range a.Blackouts {
+		if b == date {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseClock(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Clock
+		wantErr bool
+	}{
+		{in: "00:00", want: 0},
+		{in: "9:05", want: 9*60 + 5},
+		{in: "24:00", want: 24 * 60},
+		{in: "24:01", wantErr: true},
+		{in: "12:60", wantErr: true},
+		{in: "noon", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseClock(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseClock(%q) error = %v, wanted error %t", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseClock(%q) = %v, wanted %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	day := date("10/30 12:00 PM")
+	morning := []ClockRange{{From: 0, To: 10 * 60}}
+
+	for _, tc := range []struct {
+		name  string
+		avail Availability
+		// want is the start and end of each good time.
+		want [][2]string
+	}{{
+		name:  "any time",
+		avail: Availability{},
+		want:  [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name:  "mornings only",
+		avail: Availability{Weekly: map[time.Weekday][]ClockRange{day.Weekday(): morning}},
+		want:  [][2]string{{"10/30 9:40 AM", "10/30 10:00 AM"}},
+	}, {
+		name:  "mornings on other days",
+		avail: Availability{Weekly: map[time.Weekday][]ClockRange{(day.Weekday() + 1) % 7: morning}},
+		want:  [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name:  "not that day",
+		avail: Availability{Weekly: map[time.Weekday][]ClockRange{day.Weekday(): {}}},
+	}, {
+		name:  "blacked out",
+		avail: Availability{Blackouts: []string{day.Format(BlackoutFmt)}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			avail := tc.avail
+			var got [][2]string
+			for _, gt := range GoodTimes2(testDay, Options{Availability: &avail}) {
+				got = append(got, [2]string{
+					gt.Time.Truncate(time.Minute).Format("01/02 3:04 PM"),
+					gt.Time.Add(gt.Duration).Format("01/02 3:04 PM"),
+				})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("good times (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAvailabilityJSON(t *testing.T) {
+	want := Availability{
+		Weekly: map[time.Weekday][]ClockRange{
+			time.Monday: {{From: 6 * 60, To: 9 * 60}},
+			time.Sunday: {},
+		},
+		Blackouts: []string{"2022-12-25"},
+	}
+	blob, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var got Availability
+	if err := json.Unmarshal(blob, &got); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", blob, err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("round trip of %s (-want,+got):\n%s", blob, diff)
+	}
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+)
+
+// slackRate is the fastest the tide can change, in feet per hour, and still be
+// considered slack.
+const slackRate = 0.2
+
+// Direction describes which way the tide is moving.
+type Direction string
+
+const (
+	Rising  Direction = "rising"
+	Falling Direction = "falling"
+	Slack   Direction = "slack"
+)
+
+// ParseDirection returns the direction named s.
+func ParseDirection(s string) (Direction, error) {
+	switch d := Direction(s); d {
+	case Rising, Falling, Slack:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown tide direction %q", s)
+}
+
+// Classify returns the direction the tide moves over the interval, judged by
+// its overall change from start to end.
+func Classify(spl splines.Spline, iv interval.Interval) Direction {
+	hours := iv.Duration().Hours()
+	if hours == 0 {
+		if s := spl.Slope(iv.Start); math.Abs(s) > slackRate {
+			return directionOf(s)
+		}
+		return Slack
+	}
+	rate := (spl.Eval(iv.End) - spl.Eval(iv.Start)) / hours
+	if math.Abs(rate) <= slackRate {
+		return Slack
+	}
+	return directionOf(rate)
+}
+
+func directionOf(rate float64) Direction {
+	if rate > 0 {
+		return Rising
+	}
+	return Falling
+}
+
+// TideMoving returns the times when the tide described by spl is moving in the
+// given direction.
+func TideMoving(spl splines.Spline, dir Direction) interval.Set {
+	var bounds []time.Time
+	var keep func(time.Time) bool
+	switch dir {
+	case Rising:
+		bounds = spl.SlopeCrossings(0)
+		keep = func(t time.Time) bool { return spl.Slope(t) > 0 }
+	case Falling:
+		bounds = spl.SlopeCrossings(0)
+		keep = func(t time.Time) bool { return spl.Slope(t) < 0 }
+	case Slack:
+		return TideRateAtMost(spl, slackRate)
+	default:
+		return interval.Set{}
+	}
+	return splitWhere(spl, bounds, keep)
+}
+
+// TideRateAtMost returns the times when the tide described by spl changes by
+// at most rate feet per hour.
+func TideRateAtMost(spl splines.Spline, rate float64) interval.Set {
+	bounds := append(spl.SlopeCrossings(rate), spl.SlopeCrossings(-rate)...)
+	return splitWhere(spl, bounds, func(t time.Time) bool {
+		return math.Abs(spl.Slope(t)) <= rate
+	})
+}
+
+// directionReason describes how the tide moves during iv.
+func directionReason(spl splines.Spline, iv interval.Interval) Reason {
+	dir := Classify(spl, iv)
+	if dir == Slack {
+		return Reason{Kind: ReasonSlack}
+	}
+	kind := ReasonRising
+	if dir == Falling {
+		kind = ReasonFalling
+	}
+	_, rate := spl.MaxRate(iv.Start, iv.End)
+	return Reason{Kind: kind, Value: rate, Unit: UnitFeetPerHour}
+}
@@ -0,0 +1,74 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+)
+
+func TestClassify(t *testing.T) {
+	spl := splines.CurvesBetween(testDay.Tides)
+	for _, tc := range []struct {
+		name       string
+		start, end string
+		want       Direction
+	}{
+		{"ebb", "10/30 7:00 AM", "10/30 10:00 AM", Falling},
+		{"flood", "10/30 1:00 PM", "10/30 4:00 PM", Rising},
+		{"around the low", "10/30 11:00 AM", "10/30 1:00 PM", Slack},
+		{"instant at the low", "10/30 12:00 PM", "10/30 12:00 PM", Slack},
+		{"instant mid flood", "10/30 3:00 PM", "10/30 3:00 PM", Rising},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			iv := interval.Interval{Start: date(tc.start), End: date(tc.end)}
+			if got := Classify(spl, iv); got != tc.want {
+				t.Errorf("Classify(%s-%s) = %q, wanted %q", tc.start, tc.end, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTideMoving(t *testing.T) {
+	spl := splines.CurvesBetween(testDay.Tides)
+
+	if diff := cmp.Diff(interval.Set{{Start: date("10/30 6:00 AM"), End: date("10/30 12:00 PM")}}, TideMoving(spl, Falling)); diff != "" {
+		t.Errorf("TideMoving(Falling) (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff(interval.Set{{Start: date("10/30 12:00 PM"), End: date("10/30 6:00 PM")}}, TideMoving(spl, Rising)); diff != "" {
+		t.Errorf("TideMoving(Rising) (-want,+got):\n%s", diff)
+	}
+
+	// Slack water is at each end of the day and around the low.
+	slack := TideMoving(spl, Slack)
+	if len(slack) != 3 {
+		t.Fatalf("TideMoving(Slack) = %v, wanted three intervals", slack)
+	}
+	if !slack.Contains(date("10/30 12:00 PM")) || slack.Contains(date("10/30 9:00 AM")) {
+		t.Errorf("TideMoving(Slack) = %v, wanted the low but not mid ebb", slack)
+	}
+}
+
+func TestGoodTimes2Direction(t *testing.T) {
+	rising := Rising
+	got := GoodTimes2(testDay, Options{Direction: &rising})
+	if len(got) != 1 {
+		t.Fatalf("GoodTimes2 = %v, wanted one good time", got)
+	}
+	if start, want := got[0].Time, date("10/30 12:00 PM"); !start.Equal(want) {
+		t.Errorf("rising good time starts at %v, wanted %v", start, want)
+	}
+	if got[0].Direction != Rising {
+		t.Errorf("rising good time is classified %q", got[0].Direction)
+	}
+
+	// The tide never moves slowly enough during the day except around the
+	// low.
+	maxRate := 0.5
+	got = GoodTimes2(testDay, Options{MaxRate: &maxRate})
+	if len(got) != 1 || got[0].Time.Before(date("10/30 11:00 AM")) {
+		t.Errorf("GoodTimes2 with MaxRate = %v, wanted a single window near the low", got)
+	}
+}
@@ -3,68 +3,86 @@ package meta
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/interval"
 	"github.com/spencer-p/surfdash/pkg/timetricks"
 )
 
-const (
-	dayFmt  = "01/02"
-	timeFmt = "3:04 PM"
-)
-
 // GoodTime represents a good time to go surfing.
 type GoodTime struct {
-	Time     time.Time     `json:unix_time`
-	Reasons  []string      `json:"reasons"`
-	Duration time.Duration `json:"duration",omitempty`
+	Time     time.Time     `json:"Time"`
+	Reasons  []Reason      `json:"reasons"`
+	Duration time.Duration `json:"duration"`
+
+	// Direction is which way the tide moves during the good time. Optional.
+	Direction Direction `json:"direction,omitempty"`
+
+	// Score rates the good time from 0 to 100. Optional.
+	Score int `json:"score"`
 
 	// PrettyTime is a human-readable version of the time, relative to the
 	// current date. Optional.
-	PrettyTime string `json:"pretty_time",omitempty`
+	PrettyTime string `json:"pretty_time"`
 }
 
 func (gt *GoodTime) String() string {
+	return gt.StringIn(i18n.English, timetricks.Local)
+}
+
+// StringIn describes the good time for readers of the locale, with days and
+// times of the calendar.
+func (gt *GoodTime) StringIn(l i18n.Locale, cal timetricks.Calendar) string {
 	return fmt.Sprintf("%s, %s",
-		gt.prettyTime(),
-		strings.Join(gt.Reasons, " and "))
+		gt.prettyTime(l, cal),
+		JoinReasonsIn(l, gt.Reasons))
 }
 
-func (gt *GoodTime) prettyTime() string {
-	day := timetricks.Day(gt.Time)
+func (gt *GoodTime) prettyTime(l i18n.Locale, cal timetricks.Calendar) string {
+	return l.T("%s at %s%s",
+		l.DayIn(cal, gt.Time),
+		l.Time(gt.Time.In(cal.Location)),
+		gt.until(l, cal))
+}
 
-	until := ""
-	if gt.Duration != 0 {
-		until = fmt.Sprintf(" until %s", gt.Time.Add(gt.Duration).Format(timeFmt))
+// until describes the end of the good time, if it has one.
+func (gt *GoodTime) until(l i18n.Locale, cal timetricks.Calendar) string {
+	if gt.Duration == 0 {
+		return ""
 	}
-
-	return fmt.Sprintf("%s at %s%s",
-		day,
-		gt.Time.Format(timeFmt),
-		until)
+	return l.T(" until %s", l.Time(gt.Time.Add(gt.Duration).In(cal.Location)))
 }
 
-// UpdatePrettyTime makes sure that the good time's pretty time is set.
-func (gt *GoodTime) UpdatePrettyTime() {
+// UpdatePrettyTime makes sure that the good time's pretty time is set,
+// relative to today in the calendar.
+func (gt *GoodTime) UpdatePrettyTime(cal timetricks.Calendar) {
 	if gt.PrettyTime == "" {
-		gt.PrettyTime = gt.prettyTime()
+		gt.PrettyTime = gt.prettyTime(i18n.English, cal)
 	}
 }
 
 // TimeRange returns a time range for the goodtime, similar to PrettyTime
 // without the date.
 func (gt *GoodTime) TimeRange() string {
-	until := ""
-	if gt.Duration != 0 {
-		until = fmt.Sprintf(" until %s", gt.Time.Add(gt.Duration).Format(timeFmt))
-	}
-	return fmt.Sprintf("%s%s", gt.Time.Format(timeFmt), until)
+	return gt.TimeRangeIn(i18n.English, timetricks.Local)
+}
+
+// TimeRangeIn is TimeRange for readers of the locale, with times of the
+// calendar.
+func (gt *GoodTime) TimeRangeIn(l i18n.Locale, cal timetricks.Calendar) string {
+	return l.Time(gt.Time.In(cal.Location)) + gt.until(l, cal)
+}
+
+// window returns the span of the good time.
+func (gt *GoodTime) window() interval.Interval {
+	return interval.Interval{Start: gt.Time, End: gt.Time.Add(gt.Duration)}
 }
 
 func (gt *GoodTime) MarshalJSON() ([]byte, error) {
-	// Fill in pretty time if needed.
-	gt.UpdatePrettyTime()
+	// Fill in pretty time if needed. Callers that know the calendar of the
+	// reader should set it first.
+	gt.UpdatePrettyTime(timetricks.Local)
 	// Dereference is necessary to avoid infinite loop; this method
 	// only has pointer receiver.
 	return json.Marshal(*gt)
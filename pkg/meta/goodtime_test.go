@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/spencer-p/surfdash/pkg/i18n"
 	"github.com/spencer-p/surfdash/pkg/timetricks"
 )
 
@@ -19,25 +20,25 @@ func TestGoodTimeString(t *testing.T) {
 		gt: GoodTime{
 			// seconds and nseconds should be unused
 			Time:    time.Date(1999, time.January, 5, 5, 35, 20, 4, time.Local),
-			Reasons: []string{"there is no kelp"},
+			Reasons: []Reason{Note("there is no kelp")},
 		},
 		want: "01/05 at 5:35 AM, there is no kelp",
 	}, {
 		gt: GoodTime{
 			Time: timetricks.SetClock(time.Now(), 16, 27),
-			Reasons: []string{
-				"the sun is up",
-				"you will be barreled",
+			Reasons: []Reason{
+				Note("the sun is up"),
+				Note("you will be barreled"),
 			},
 		},
 		want: "Today at 4:27 PM, the sun is up and you will be barreled",
 	}, {
 		gt: GoodTime{
 			Time: timetricks.SetClock(time.Now().Add(24*time.Hour), 12, 55),
-			Reasons: []string{
-				"the sun is up",
-				"you will be barreled",
-				"it's lunch time",
+			Reasons: []Reason{
+				Note("the sun is up"),
+				Note("you will be barreled"),
+				Note("it's lunch time"),
 			},
 		},
 		want: "Tomorrow at 12:55 PM, the sun is up and you will be barreled and it's lunch time",
@@ -46,7 +47,7 @@ func TestGoodTimeString(t *testing.T) {
 			// Set the time to three days from now so as not to trigger
 			// today/tomorrow behavior.
 			Time:    timetricks.SetClock(time.Now().Add(3*24*time.Hour), 13, 0),
-			Reasons: []string{"the weather is nice"},
+			Reasons: []Reason{Note("the weather is nice")},
 		},
 		want: fmt.Sprintf("%s at 1:00 PM, the weather is nice", time.Now().Add(3*24*time.Hour).Weekday().String()),
 	}}
@@ -64,7 +65,7 @@ func TestGoodTimeString(t *testing.T) {
 func TestGoodTimeRoundTrip(t *testing.T) {
 	gt := GoodTime{
 		Time:    time.Date(1999, time.January, 5, 5, 35, 20, 4, time.Local),
-		Reasons: []string{"there is no kelp"},
+		Reasons: []Reason{Note("there is no kelp")},
 	}
 
 	blob, err := json.Marshal(&gt)
@@ -80,3 +81,26 @@ func TestGoodTimeRoundTrip(t *testing.T) {
 		t.Errorf("failed round trip (-want,+got):\n%s", diff)
 	}
 }
+
+func TestGoodTimeStringIn(t *testing.T) {
+	cal := timetricks.Calendar{
+		Clock:    timetricks.Fixed(time.Date(1999, time.January, 4, 12, 0, 0, 0, time.UTC)),
+		Location: time.UTC,
+	}
+	gt := GoodTime{
+		Time:     time.Date(1999, time.January, 5, 5, 35, 20, 4, time.UTC),
+		Duration: 90 * time.Minute,
+		Reasons: []Reason{
+			tideReason(0.5),
+			minutesReason(ReasonBeforeSunrise, 20*time.Minute),
+		},
+	}
+	spanish := i18n.ForLanguage("es")
+	if got, want := gt.StringIn(spanish, cal), "Mañana a las 05:35 hasta las 07:05, la marea está baja a 0.50ft y solo 20 minutos antes del amanecer"; got != want {
+		t.Errorf("StringIn(es) = %q, wanted %q", got, want)
+	}
+	spanish.Hour24 = false
+	if got, want := gt.TimeRangeIn(spanish, cal), "5:35 AM hasta las 7:05 AM"; got != want {
+		t.Errorf("TimeRangeIn(es) = %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package meta
+
+import (
+	"sort"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// UsableLight returns the times when there is enough light to surf. That is
+// each day from firstLightThresh before sunrise until firstLightThresh after
+// sunset.
+func UsableLight(evs sunset.SunEvents) interval.Set {
+	return lightWithin(evs, firstLightThresh)
+}
+
+// lightWithin returns each day from twilight before sunrise until twilight
+// after sunset.
+func lightWithin(evs sunset.SunEvents, twilight time.Duration) interval.Set {
+	var days []interval.Interval
+	for i := 0; i+1 < len(evs); i++ {
+		if evs[i].Event != sunset.Sunrise || evs[i+1].Event != sunset.Sunset {
+			continue
+		}
+		days = append(days, interval.Interval{
+			Start: evs[i].Time.Add(-twilight),
+			End:   evs[i+1].Time.Add(twilight),
+		})
+	}
+	return interval.New(days...)
+}
+
+// TideInRange returns the times when the tide described by spl is between low
+// and high, inclusive.
+func TideInRange(spl splines.Spline, low, high float64) interval.Set {
+	if len(spl) == 0 {
+		return interval.Set{}
+	}
+
+	// The tide can only enter or leave the range where it crosses one of the
+	// thresholds, so the spline is split at those points and each piece is
+	// either entirely in range or entirely out of it.
+	bounds := append(spl.Crossings(low), spl.Crossings(high)...)
+	return splitWhere(spl, bounds, func(t time.Time) bool {
+		h := spl.Eval(t)
+		return h >= low && h <= high
+	})
+}
+
+// splitWhere splits the domain of spl at bounds and returns the pieces for
+// which keep is true at their midpoint. Every point where keep may change must
+// be in bounds.
+func splitWhere(spl splines.Spline, bounds []time.Time, keep func(time.Time) bool) interval.Set {
+	if len(spl) == 0 {
+		return interval.Set{}
+	}
+	all := make([]time.Time, 0, len(bounds)+2)
+	all = append(all, spl[0].Start)
+	all = append(all, bounds...)
+	all = append(all, spl[len(spl)-1].End)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Before(all[j])
+	})
+
+	var result []interval.Interval
+	for i := 0; i+1 < len(all); i++ {
+		piece := interval.Interval{Start: all[i], End: all[i+1]}
+		if piece.Empty() {
+			continue
+		}
+		if keep(piece.Start.Add(piece.Duration() / 2)) {
+			result = append(result, piece)
+		}
+	}
+	return interval.New(result...)
+}
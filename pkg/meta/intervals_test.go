@@ -0,0 +1,176 @@
+package meta
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// testDay is a day with a low tide at noon and an early sunset.
+var testDay = Conditions{
+	Tides: noaa.Predictions{
+		{Time: noaa.Time(date("10/30 6:00 AM")), Height: 5, Type: noaa.HighTide},
+		{Time: noaa.Time(date("10/30 12:00 PM")), Height: -1, Type: noaa.LowTide},
+		{Time: noaa.Time(date("10/30 6:00 PM")), Height: 5, Type: noaa.HighTide},
+	},
+	SunEvents: sunset.SunEvents{
+		{Time: date("10/30 7:00 AM"), Event: sunset.Sunrise},
+		{Time: date("10/30 1:00 PM"), Event: sunset.Sunset},
+	},
+}
+
+func TestUsableLight(t *testing.T) {
+	got := UsableLight(sunset.SunEvents{
+		// A leading sunset without its sunrise is ignored.
+		{Time: date("10/29 6:00 PM"), Event: sunset.Sunset},
+		{Time: date("10/30 7:00 AM"), Event: sunset.Sunrise},
+		{Time: date("10/30 6:00 PM"), Event: sunset.Sunset},
+		{Time: date("10/31 7:00 AM"), Event: sunset.Sunrise},
+	})
+	want := interval.Set{{
+		Start: date("10/30 6:30 AM"),
+		End:   date("10/30 6:30 PM"),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("UsableLight (-want,+got):\n%s", diff)
+	}
+}
+
+func TestTideInRange(t *testing.T) {
+	spl := splines.CurvesBetween(testDay.Tides)
+	got := TideInRange(spl, -1000, 1)
+	if len(got) != 1 {
+		t.Fatalf("TideInRange = %v, wanted a single interval", got)
+	}
+	for _, tc := range []struct {
+		at   string
+		want bool
+	}{
+		{"10/30 6:00 AM", false},
+		{"10/30 11:00 AM", true},
+		{"10/30 12:00 PM", true},
+		{"10/30 1:00 PM", true},
+		{"10/30 5:00 PM", false},
+	} {
+		if in := got.Contains(date(tc.at)); in != tc.want {
+			t.Errorf("tide in range at %s = %t, wanted %t", tc.at, in, tc.want)
+		}
+	}
+	if h := spl.Eval(got[0].Start); math.Abs(h-1) > 0.001 {
+		t.Errorf("tide at start of range is %fft, wanted exactly the threshold", h)
+	}
+
+	if got := TideInRange(nil, -1000, 1); len(got) != 0 {
+		t.Errorf("TideInRange on no data = %v, wanted nothing", got)
+	}
+}
+
+func TestGoodTimes2(t *testing.T) {
+	got := GoodTimes2(testDay, Options{})
+	if len(got) != 1 {
+		t.Fatalf("GoodTimes2 = %v, wanted one good time", got)
+	}
+
+	// The window opens when the tide drops below a foot and closes at last
+	// light, half an hour after sunset.
+	gt := got[0]
+	if got, want := gt.Time.Truncate(time.Minute), date("10/30 9:40 AM"); !got.Equal(want) {
+		t.Errorf("good time starts at %v, wanted %v", got, want)
+	}
+	if end, want := gt.Time.Add(gt.Duration), date("10/30 1:30 PM"); !end.Equal(want) {
+		t.Errorf("good time ends at %v, wanted %v", end, want)
+	}
+	wantReasons := []string{
+		"tide is 1.0ft at 9:40 AM",
+		"tide is -1.0ft at 12:00 PM",
+		"tide is -0.1ft at 1:30 PM",
+		"tide is falling at up to 1.4ft/hr",
+	}
+	if diff := cmp.Diff(wantReasons, reasonStrings(gt.Reasons)); diff != "" {
+		t.Errorf("reasons (-want,+got):\n%s", diff)
+	}
+
+	if got := GoodTimes2(Conditions{}, Options{}); len(got) != 0 {
+		t.Errorf("GoodTimes2 with no conditions = %v, wanted nothing", got)
+	}
+}
+
+func TestGoodTimes2MergeAndMinDuration(t *testing.T) {
+	// Being busy for ten minutes splits the good time in two.
+	split := &Availability{Weekly: map[time.Weekday][]ClockRange{
+		date("10/30 12:00 PM").Weekday(): {
+			{From: 9 * 60, To: 11 * 60},
+			{From: 11*60 + 10, To: 24 * 60},
+		},
+	}}
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		// want is the start and end of each good time.
+		want [][2]string
+	}{{
+		name: "split",
+		opts: Options{Availability: split},
+		want: [][2]string{
+			{"10/30 9:40 AM", "10/30 11:00 AM"},
+			{"10/30 11:10 AM", "10/30 1:30 PM"},
+		},
+	}, {
+		name: "gap too long to merge",
+		opts: Options{Availability: split, MergeGap: 5 * time.Minute},
+		want: [][2]string{
+			{"10/30 9:40 AM", "10/30 11:00 AM"},
+			{"10/30 11:10 AM", "10/30 1:30 PM"},
+		},
+	}, {
+		name: "merged",
+		opts: Options{Availability: split, MergeGap: 10 * time.Minute},
+		want: [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name: "short one dropped",
+		opts: Options{Availability: split, MinDuration: 90 * time.Minute},
+		want: [][2]string{{"10/30 11:10 AM", "10/30 1:30 PM"}},
+	}, {
+		name: "merged before dropping",
+		opts: Options{Availability: split, MergeGap: time.Hour, MinDuration: 3 * time.Hour},
+		want: [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got [][2]string
+			for _, gt := range GoodTimes2(testDay, tc.opts) {
+				got = append(got, [2]string{
+					gt.Time.Truncate(time.Minute).Format("01/02 3:04 PM"),
+					gt.Time.Add(gt.Duration).Format("01/02 3:04 PM"),
+				})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("good times (-want,+got):\n%s", diff)
+			}
+		})
+	}
+
+	merged := GoodTimes2(testDay, Options{Availability: split, MergeGap: time.Hour})
+	if len(merged) != 1 {
+		t.Fatalf("GoodTimes2 = %v, wanted one good time", merged)
+	}
+	wantReasons := []string{
+		"tide is 1.0ft at 9:40 AM",
+		"tide is -0.6ft at 11:00 AM",
+		"tide is falling at up to 1.4ft/hr",
+		"tide is -0.7ft at 11:10 AM",
+		"tide is -1.0ft at 12:00 PM",
+		"tide is -0.1ft at 1:30 PM",
+		"tide is rising at up to 1.1ft/hr",
+	}
+	if diff := cmp.Diff(wantReasons, reasonStrings(merged[0].Reasons)); diff != "" {
+		t.Errorf("merged reasons (-want,+got):\n%s", diff)
+	}
+}
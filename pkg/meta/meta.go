@@ -2,11 +2,9 @@ package meta
 
 import (
 	"errors"
-	"fmt"
-	"math"
-	"sort"
 	"time"
 
+	"github.com/spencer-p/surfdash/pkg/interval"
 	"github.com/spencer-p/surfdash/pkg/noaa"
 	"github.com/spencer-p/surfdash/pkg/noaa/splines"
 	"github.com/spencer-p/surfdash/pkg/sunset"
@@ -24,6 +22,39 @@ var notFound = errors.New("not found")
 type Conditions struct {
 	Tides     noaa.Predictions
 	SunEvents sunset.SunEvents
+
+	// Model interpolates Tides. Optional; defaults to splines.Cubic.
+	Model splines.Model
+
+	// spl caches the interpolated Tides while rules are evaluated.
+	spl splines.Spline
+}
+
+func (c Conditions) model() splines.Model {
+	if c.Model == nil {
+		return splines.Cubic
+	}
+	return c.Model
+}
+
+// spline returns the tide between the predictions.
+func (c Conditions) spline() splines.Spline {
+	if c.spl != nil {
+		return c.spl
+	}
+	return c.model().Interpolate(c.Tides)
+}
+
+// domain returns the time covered by the tide predictions.
+func (c Conditions) domain() interval.Set {
+	spl := c.spline()
+	if len(spl) == 0 {
+		return interval.Set{}
+	}
+	return interval.New(interval.Interval{
+		Start: spl[0].Start,
+		End:   spl[len(spl)-1].End,
+	})
 }
 
 // GoodTimes analyzes a set of Conditions to find good times to surf.
@@ -44,8 +75,8 @@ func GoodTimes(c Conditions) []GoodTime {
 		t := time.Time(tide.Time)
 
 		// Find last sun event that comes before the tide event
-		suni, err := indexOfLastEventBefore(t, c.SunEvents)
-		if err != nil {
+		suni := c.SunEvents.Search(t) - 1
+		if suni < 0 {
 			// No time before this event.
 			// It is possible it happens before sunrise.
 			if len(c.SunEvents) > 0 && c.SunEvents[0].Event == sunset.Sunrise {
@@ -64,9 +95,9 @@ func GoodTimes(c Conditions) []GoodTime {
 				// Unless it's close to right after sunset
 				result = append(result, GoodTime{
 					Time: t,
-					Reasons: []string{
-						tideReason(tide.Height),
-						fmt.Sprintf("%.0f minutes after sunset", diff.Minutes()),
+					Reasons: []Reason{
+						tideReason(float64(tide.Height)),
+						minutesReason(ReasonAfterSunset, diff),
 					},
 				})
 
@@ -80,8 +111,8 @@ func GoodTimes(c Conditions) []GoodTime {
 			// Low tide during the day
 			result = append(result, GoodTime{
 				Time: t,
-				Reasons: []string{
-					tideReason(tide.Height),
+				Reasons: []Reason{
+					tideReason(float64(tide.Height)),
 				},
 			})
 			continue
@@ -92,10 +123,6 @@ func GoodTimes(c Conditions) []GoodTime {
 	return result
 }
 
-func tideReason(height noaa.Height) string {
-	return fmt.Sprintf("tide is low at %.2fft", height)
-}
-
 // dawnPatrol finds a GoodTime before dawn.
 func dawnPatrol(tide noaa.Prediction, event sunset.SunEvent) (GoodTime, error) {
 	t := time.Time(tide.Time)
@@ -105,30 +132,13 @@ func dawnPatrol(tide noaa.Prediction, event sunset.SunEvent) (GoodTime, error) {
 	}
 	return GoodTime{
 		Time: t,
-		Reasons: []string{
-			tideReason(tide.Height),
-			fmt.Sprintf("only %.0f minutes before sunrise", diff.Minutes()),
+		Reasons: []Reason{
+			tideReason(float64(tide.Height)),
+			minutesReason(ReasonBeforeSunrise, diff),
 		},
 	}, nil
 }
 
-// Returns last event before time t, or an error if there is none.
-func indexOfLastEventBefore(t time.Time, events sunset.SunEvents) (int, error) {
-	// Remember, sort.Search finds the FIRST element. We have to reverse the
-	// index.
-	n := len(events)
-	revi := sort.Search(n, func(revtesti int) bool {
-		testi := n - 1 - revtesti
-		return events[testi].Time.Before(t)
-	})
-	result := n - 1 - revi
-	if result < 0 || result >= n {
-		// no element found
-		return -1, notFound
-	}
-	return result, nil
-}
-
 // Options specifies options to tune GoodTimes.
 type Options struct {
 	// When LowTideThresh and HighTideThresh are specified,
@@ -137,65 +147,125 @@ type Options struct {
 	LowTideThresh  *float64
 	HighTideThresh *float64
 
+	// Direction, when specified, restricts the resulting GoodTimes to when
+	// the tide is moving that way.
+	Direction *Direction
+
+	// MaxRate, when specified, restricts the resulting GoodTimes to when the
+	// tide changes by at most this many feet per hour.
+	MaxRate *float64
+
+	// Days, when not empty, restricts the resulting GoodTimes to those days
+	// of the week.
+	Days []time.Weekday
+
+	// Availability, when specified, restricts the resulting GoodTimes to when
+	// the user is available.
+	Availability *Availability
+
+	// Extra rules that the resulting GoodTimes must also pass.
+	Extra []Rule
+
+	// Factors score the resulting GoodTimes. Optional; defaults to
+	// DefaultFactors.
+	Factors []Factor
+
+	// MergeGap, when positive, merges GoodTimes that are separated by at most
+	// this long.
+	MergeGap time.Duration
+
+	// MinDuration, when positive, drops GoodTimes shorter than this, after
+	// merging.
+	MinDuration time.Duration
+
 	// Represents the default values. Optional.
 	DefaultLowTide, DefaultHighTide *float64
 }
 
-// GoodTimes2 is like GoodTimes but better.
+// Rules returns the rules that the options describe, followed by Extra.
+func (o Options) Rules() []Rule {
+	o.ApplyDefaults()
+	rules := []Rule{
+		TideRange{Low: *o.LowTideThresh, High: *o.HighTideThresh},
+		Daylight{Twilight: firstLightThresh},
+		TideTrend{Direction: o.Direction, MaxRate: o.MaxRate},
+	}
+	if len(o.Days) > 0 {
+		rules = append(rules, DayOfWeek(o.Days))
+	}
+	if o.Availability != nil {
+		rules = append(rules, *o.Availability)
+	}
+	return append(rules, o.Extra...)
+}
+
+// GoodTimes2 is like GoodTimes but better. It finds the times when the tide is
+// within the thresholds of opts and there is usable light, and scores them.
 func GoodTimes2(c Conditions, opts Options) []GoodTime {
-	opts.ApplyDefaults()
-	result := []GoodTime{}
-	preds := c.Tides
-
-	tstart := time.Time(preds[0].Time)
-	tend := time.Time(preds[len(preds)-1].Time)
-	const step = 5 * time.Minute
-
-	spl := splines.CurvesBetween(preds)
-
-	for t := tstart; t.Before(tend); t = t.Add(step) {
-		var gt GoodTime
-		low := math.MaxFloat64
-		var lowt time.Time
-		for ; t.Before(tend); t = t.Add(step) {
-			// If no desired tide, bail.
-			tideHeight := spl.Eval(t)
-			if tideHeight > *opts.HighTideThresh || tideHeight < *opts.LowTideThresh {
-				break
-			}
+	if len(c.Tides) < 2 {
+		return []GoodTime{}
+	}
+	c.spl = c.spline()
 
-			// If the sun won't be shining, bail.
-			if light := c.SunEvents.SunUp(t) || c.SunEvents.Dawn(t) || c.SunEvents.Dusk(t); !light {
-				break
-			}
+	factors := opts.Factors
+	if factors == nil {
+		factors = DefaultFactors
+	}
+	result := FindGoodTimes(c, opts.Rules()...)
+	result = mergeGoodTimes(c, result, opts.MergeGap)
+	result = dropShort(result, opts.MinDuration)
+	for i := range result {
+		result[i].Score = Score(c, result[i].window(), factors)
+	}
+	return result
+}
 
-			// Set the start time of this good time if needed and update the
-			// duration to match.
-			if gt.Time.IsZero() {
-				gt.Time = t
-			}
-			gt.Duration = t.Sub(gt.Time) // + step
+// mergeGoodTimes merges consecutive good times separated by at most gap. A
+// merged good time has the reasons of each of its parts.
+func mergeGoodTimes(c Conditions, gts []GoodTime, gap time.Duration) []GoodTime {
+	if gap <= 0 {
+		return gts
+	}
+	result := []GoodTime{}
+	for _, gt := range gts {
+		last := len(result) - 1
+		if last < 0 || gt.Time.Sub(result[last].window().End) > gap {
+			result = append(result, gt)
+			continue
+		}
+		merged := &result[last]
+		merged.Duration = gt.window().End.Sub(merged.Time)
+		merged.Direction = Classify(c.spline(), merged.window())
+		merged.Reasons = appendNew(merged.Reasons, gt.Reasons...)
+	}
+	return result
+}
 
-			// TODO Add reasons in a reasonable way.
-			if tideHeight < low {
-				low = tideHeight
-				lowt = t
+// appendNew appends the reasons that are not already in list.
+func appendNew(list []Reason, reasons ...Reason) []Reason {
+	for _, s := range reasons {
+		found := false
+		for _, have := range list {
+			if have.equal(s) {
+				found = true
+				break
 			}
 		}
-		if !gt.Time.IsZero() {
-			if !gt.Time.Equal(lowt) {
-				// The lowest part of good time is not the first time bucket.
-				// This means we can specify the tide height at the start
-				// without being redundant.
-				gt.Reasons = append(gt.Reasons, fmt.Sprintf("tide is %.1fft at %s", noaa.Height(spl.Eval(gt.Time)), gt.Time.Format(timeFmt)))
-			}
-			gt.Reasons = append(gt.Reasons, fmt.Sprintf("tide is %.1fft at %s", noaa.Height(low), lowt.Format(timeFmt)))
-			tend := gt.Time.Add(gt.Duration)
-			if !tend.Equal(lowt) {
-				// The lowest part is not the last time bucket.
-				// Again, we can be more detailed without being redundant.
-				gt.Reasons = append(gt.Reasons, fmt.Sprintf("tide is %.1fft at %s", noaa.Height(spl.Eval(tend)), tend.Format(timeFmt)))
-			}
+		if !found {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// dropShort removes good times shorter than min.
+func dropShort(gts []GoodTime, min time.Duration) []GoodTime {
+	if min <= 0 {
+		return gts
+	}
+	result := []GoodTime{}
+	for _, gt := range gts {
+		if gt.Duration >= min {
 			result = append(result, gt)
 		}
 	}
@@ -1,7 +1,6 @@
 package meta
 
 import (
-	"fmt"
 	"testing"
 	"time"
 
@@ -54,7 +53,7 @@ func TestGoodTimes(t *testing.T) {
 			want: []GoodTime{
 				GoodTime{
 					Time:    date("10/30 1:00 PM"),
-					Reasons: []string{tideReason(0.5)},
+					Reasons: []Reason{tideReason(0.5)},
 				},
 			},
 		},
@@ -81,9 +80,9 @@ func TestGoodTimes(t *testing.T) {
 			want: []GoodTime{
 				GoodTime{
 					Time: date("10/30 6:00 AM"),
-					Reasons: []string{
+					Reasons: []Reason{
 						tideReason(0.5),
-						fmt.Sprintf("only %d minutes before sunrise", 20)},
+						minutesReason(ReasonBeforeSunrise, 20*time.Minute)},
 				},
 			},
 		},
@@ -110,9 +109,9 @@ func TestGoodTimes(t *testing.T) {
 			want: []GoodTime{
 				GoodTime{
 					Time: date("10/30 6:20 PM"),
-					Reasons: []string{
+					Reasons: []Reason{
 						tideReason(0.5),
-						fmt.Sprintf("%d minutes after sunset", 20),
+						minutesReason(ReasonAfterSunset, 20*time.Minute),
 					},
 				},
 			},
@@ -0,0 +1,157 @@
+package meta
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/i18n"
+)
+
+// ReasonKind is what a Reason is about.
+type ReasonKind string
+
+const (
+	// ReasonLowTide is the height of a low tide.
+	ReasonLowTide ReasonKind = "low_tide"
+	// ReasonTideHeight is the height of the tide at Time.
+	ReasonTideHeight ReasonKind = "tide_height"
+	// ReasonAfterSunset is how long after sunset a good time is.
+	ReasonAfterSunset ReasonKind = "after_sunset"
+	// ReasonBeforeSunrise is how long before sunrise a good time is.
+	ReasonBeforeSunrise ReasonKind = "before_sunrise"
+	// ReasonSlack is a tide that barely moves.
+	ReasonSlack ReasonKind = "tide_slack"
+	// ReasonRising and ReasonFalling are the fastest the tide moves.
+	ReasonRising  ReasonKind = "tide_rising"
+	ReasonFalling ReasonKind = "tide_falling"
+	// ReasonNote is free text, for rules outside this package.
+	ReasonNote ReasonKind = "note"
+)
+
+// Units of the value of a Reason.
+const (
+	UnitFeet        = "ft"
+	UnitMinutes     = "min"
+	UnitFeetPerHour = "ft/hr"
+)
+
+// Reason explains why a good time is good.
+type Reason struct {
+	Kind ReasonKind
+	// Value is measured in Unit. Reasons without a Unit have no value.
+	Value float64
+	Unit  string
+	// Time is when Value holds. Optional.
+	Time time.Time
+	// Text is the text of a ReasonNote.
+	Text string
+}
+
+// Note makes a Reason of free text.
+func Note(text string) Reason {
+	return Reason{Kind: ReasonNote, Text: text}
+}
+
+func heightReason(h float64, t time.Time) Reason {
+	return Reason{Kind: ReasonTideHeight, Value: h, Unit: UnitFeet, Time: t}
+}
+
+func tideReason(h float64) Reason {
+	return Reason{Kind: ReasonLowTide, Value: h, Unit: UnitFeet}
+}
+
+func minutesReason(kind ReasonKind, d time.Duration) Reason {
+	return Reason{Kind: kind, Value: d.Minutes(), Unit: UnitMinutes}
+}
+
+func (r Reason) String() string {
+	return r.StringIn(i18n.English)
+}
+
+// StringIn describes the reason for readers of the locale. Notes are not
+// translated.
+func (r Reason) StringIn(l i18n.Locale) string {
+	switch r.Kind {
+	case ReasonLowTide:
+		return l.T("tide is low at %.2fft", r.Value)
+	case ReasonTideHeight:
+		return l.T("tide is %.1fft at %s", r.Value, l.Time(r.Time))
+	case ReasonAfterSunset:
+		return l.T("%.0f minutes after sunset", r.Value)
+	case ReasonBeforeSunrise:
+		return l.T("only %.0f minutes before sunrise", r.Value)
+	case ReasonSlack:
+		return l.T("tide is slack")
+	case ReasonRising:
+		return l.T("tide is rising at up to %.1fft/hr", r.Value)
+	case ReasonFalling:
+		return l.T("tide is falling at up to %.1fft/hr", r.Value)
+	default:
+		return r.Text
+	}
+}
+
+// equal is true if r and o are the same reason, even if their times are in
+// different locations.
+func (r Reason) equal(o Reason) bool {
+	return r.Kind == o.Kind &&
+		r.Value == o.Value &&
+		r.Unit == o.Unit &&
+		r.Time.Equal(o.Time) &&
+		r.Text == o.Text
+}
+
+// reasonJSON is the encoding of a Reason. Text is always the String of the
+// reason, so clients that do not know a kind can still show it.
+type reasonJSON struct {
+	Kind  ReasonKind `json:"kind"`
+	Value *float64   `json:"value,omitempty"`
+	Unit  string     `json:"unit,omitempty"`
+	Time  *time.Time `json:"time,omitempty"`
+	Text  string     `json:"text"`
+}
+
+func (r Reason) MarshalJSON() ([]byte, error) {
+	out := reasonJSON{Kind: r.Kind, Unit: r.Unit, Text: r.String()}
+	if r.Unit != "" {
+		out.Value = &r.Value
+	}
+	if !r.Time.IsZero() {
+		out.Time = &r.Time
+	}
+	return json.Marshal(out)
+}
+
+func (r *Reason) UnmarshalJSON(buf []byte) error {
+	var in reasonJSON
+	if err := json.Unmarshal(buf, &in); err != nil {
+		return err
+	}
+	*r = Reason{Kind: in.Kind, Unit: in.Unit}
+	if in.Value != nil {
+		r.Value = *in.Value
+	}
+	if in.Time != nil {
+		r.Time = *in.Time
+	}
+	if r.Kind == ReasonNote || r.Kind == "" {
+		r.Kind = ReasonNote
+		r.Text = in.Text
+	}
+	return nil
+}
+
+// JoinReasons lists the reasons in a sentence.
+func JoinReasons(reasons []Reason) string {
+	return JoinReasonsIn(i18n.English, reasons)
+}
+
+// JoinReasonsIn lists the reasons in a sentence for readers of the locale.
+func JoinReasonsIn(l i18n.Locale, reasons []Reason) string {
+	strs := make([]string, len(reasons))
+	for i, r := range reasons {
+		strs[i] = r.StringIn(l)
+	}
+	return strings.Join(strs, l.T(" and "))
+}
@@ -0,0 +1,92 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// reasonStrings renders reasons to compare them as text.
+func reasonStrings(reasons []Reason) []string {
+	var strs []string
+	for _, r := range reasons {
+		strs = append(strs, r.String())
+	}
+	return strs
+}
+
+func TestReasonString(t *testing.T) {
+	for _, tc := range []struct {
+		in   Reason
+		want string
+	}{{
+		in:   tideReason(0.5),
+		want: "tide is low at 0.50ft",
+	}, {
+		in:   heightReason(-1.04, date("10/30 9:40 AM")),
+		want: "tide is -1.0ft at 9:40 AM",
+	}, {
+		in:   minutesReason(ReasonAfterSunset, 20*time.Minute),
+		want: "20 minutes after sunset",
+	}, {
+		in:   minutesReason(ReasonBeforeSunrise, 20*time.Minute),
+		want: "only 20 minutes before sunrise",
+	}, {
+		in:   Reason{Kind: ReasonSlack},
+		want: "tide is slack",
+	}, {
+		in:   Reason{Kind: ReasonRising, Value: 1.12, Unit: UnitFeetPerHour},
+		want: "tide is rising at up to 1.1ft/hr",
+	}, {
+		in:   Reason{Kind: ReasonFalling, Value: 1.36, Unit: UnitFeetPerHour},
+		want: "tide is falling at up to 1.4ft/hr",
+	}, {
+		in:   Note("there is no kelp"),
+		want: "there is no kelp",
+	}} {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.in.String(); got != tc.want {
+				t.Errorf("String() = %q, wanted %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReasonJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   Reason
+		want string
+	}{{
+		name: "height",
+		in:   heightReason(0, time.Date(2021, time.April, 3, 6, 30, 0, 0, time.UTC)),
+		want: `{"kind":"tide_height","value":0,"unit":"ft","time":"2021-04-03T06:30:00Z","text":"tide is 0.0ft at 6:30 AM"}`,
+	}, {
+		name: "no value",
+		in:   Reason{Kind: ReasonSlack},
+		want: `{"kind":"tide_slack","text":"tide is slack"}`,
+	}, {
+		name: "note",
+		in:   Note("it's long"),
+		want: `{"kind":"note","text":"it's long"}`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			blob, err := json.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("Marshal() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(blob)); diff != "" {
+				t.Errorf("Marshal() (-want,+got):\n%s", diff)
+			}
+			var got Reason
+			if err := json.Unmarshal(blob, &got); err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+			if diff := cmp.Diff(tc.in, got); diff != "" {
+				t.Errorf("round trip (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package meta
+
+import (
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+)
+
+// A Rule is a criterion for a good time.
+type Rule interface {
+	// Evaluate reports whether the window w passes the rule under the given
+	// conditions, with reasons to show the user when it does.
+	Evaluate(c Conditions, w interval.Interval) (ok bool, reasons []Reason)
+}
+
+// RuleFunc adapts a function to a Rule.
+type RuleFunc func(c Conditions, w interval.Interval) (bool, []Reason)
+
+func (f RuleFunc) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	return f(c, w)
+}
+
+// A Limiter is a Rule that can compute every time it allows. FindGoodTimes
+// uses it to narrow the windows it considers before evaluating any rules.
+type Limiter interface {
+	Rule
+	// Allowed returns the times that pass the rule.
+	Allowed(c Conditions) interval.Set
+}
+
+// FindGoodTimes returns the windows of time that pass every rule. Each
+// window is as long as the Limiters allow, and the reasons of each rule are
+// listed in order.
+func FindGoodTimes(c Conditions, rules ...Rule) []GoodTime {
+	result := []GoodTime{}
+	if len(c.Tides) < 2 {
+		return result
+	}
+	c.spl = c.spline()
+
+	windows := c.domain()
+	for _, r := range rules {
+		if l, ok := r.(Limiter); ok {
+			windows = windows.Intersect(l.Allowed(c))
+		}
+	}
+
+nextWindow:
+	for _, w := range windows {
+		gt := GoodTime{
+			Time:      w.Start,
+			Duration:  w.Duration(),
+			Direction: Classify(c.spl, w),
+		}
+		for _, r := range rules {
+			ok, reasons := r.Evaluate(c, w)
+			if !ok {
+				continue nextWindow
+			}
+			gt.Reasons = append(gt.Reasons, reasons...)
+		}
+		result = append(result, gt)
+	}
+	return result
+}
+
+// TideRange passes when the tide stays between Low and High feet, inclusive.
+type TideRange struct {
+	Low, High float64
+}
+
+func (r TideRange) Allowed(c Conditions) interval.Set {
+	return TideInRange(c.spline(), r.Low, r.High)
+}
+
+// Evaluate describes the tide at the start, the end, and the lowest point of
+// the window.
+func (r TideRange) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	if !r.Allowed(c).Covers(w) {
+		return false, nil
+	}
+	spl := c.spline()
+	var reasons []Reason
+	lowt, low := spl.Min(w.Start, w.End)
+	if !w.Start.Equal(lowt) {
+		// The lowest part of good time is not the start.
+		// This means we can specify the tide height at the start
+		// without being redundant.
+		reasons = append(reasons, heightReason(spl.Eval(w.Start), w.Start))
+	}
+	reasons = append(reasons, heightReason(low, lowt))
+	if !w.End.Equal(lowt) {
+		// The lowest part is not the end.
+		// Again, we can be more detailed without being redundant.
+		reasons = append(reasons, heightReason(spl.Eval(w.End), w.End))
+	}
+	return true, reasons
+}
+
+// Daylight passes when there is usable light, from Twilight before sunrise
+// until Twilight after sunset.
+type Daylight struct {
+	Twilight time.Duration
+}
+
+func (d Daylight) Allowed(c Conditions) interval.Set {
+	return lightWithin(c.SunEvents, d.Twilight)
+}
+
+func (d Daylight) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	return d.Allowed(c).Covers(w), nil
+}
+
+// TideTrend passes when the tide moves in Direction at no more than MaxRate
+// feet per hour. Either may be nil to allow anything. Its reason describes how
+// the tide moves regardless.
+type TideTrend struct {
+	Direction *Direction
+	MaxRate   *float64
+}
+
+func (r TideTrend) Allowed(c Conditions) interval.Set {
+	spl := c.spline()
+	allowed := c.domain()
+	if r.Direction != nil {
+		allowed = allowed.Intersect(TideMoving(spl, *r.Direction))
+	}
+	if r.MaxRate != nil {
+		allowed = allowed.Intersect(TideRateAtMost(spl, *r.MaxRate))
+	}
+	return allowed
+}
+
+func (r TideTrend) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	if !r.Allowed(c).Covers(w) {
+		return false, nil
+	}
+	return true, []Reason{directionReason(c.spline(), w)}
+}
+
+// DayOfWeek passes on the listed days, in the time zone of the tide
+// predictions.
+type DayOfWeek []time.Weekday
+
+func (d DayOfWeek) Allowed(c Conditions) interval.Set {
+	domain := c.domain()
+	if len(domain) == 0 {
+		return domain
+	}
+	return d.within(domain[0].Start, domain[0].End)
+}
+
+func (d DayOfWeek) Evaluate(c Conditions, w interval.Interval) (bool, []Reason) {
+	return d.within(w.Start, w.End).Covers(w), nil
+}
+
+// within returns the listed days that overlap start to end.
+func (d DayOfWeek) within(start, end time.Time) interval.Set {
+	var days []interval.Interval
+	y, m, dd := start.Date()
+	for day := time.Date(y, m, dd, 0, 0, 0, 0, start.Location()); !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !d.has(day.Weekday()) {
+			continue
+		}
+		days = append(days, interval.Interval{
+			Start: day,
+			End:   day.AddDate(0, 0, 1),
+		})
+	}
+	return interval.New(days...)
+}
+
+func (d DayOfWeek) has(wd time.Weekday) bool {
+	for _, want := range d {
+		if want == wd {
+			return true
+		}
+	}
+	return false
+}
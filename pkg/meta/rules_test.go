@@ -0,0 +1,110 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+)
+
+func TestFindGoodTimes(t *testing.T) {
+	day := date("10/30 12:00 PM").Weekday()
+	rising := Rising
+
+	long := RuleFunc(func(c Conditions, w interval.Interval) (bool, []Reason) {
+		return w.Duration() >= 3*time.Hour, []Reason{Note("it's long")}
+	})
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		// want is the start and end of each good time.
+		want [][2]string
+	}{{
+		name: "defaults",
+		want: [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name: "on the right day",
+		opts: Options{Days: []time.Weekday{day}},
+		want: [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name: "on the wrong day",
+		opts: Options{Days: []time.Weekday{(day + 1) % 7}},
+	}, {
+		name: "rising",
+		opts: Options{Direction: &rising},
+		want: [][2]string{{"10/30 12:00 PM", "10/30 1:30 PM"}},
+	}, {
+		name: "extra rule passes",
+		opts: Options{Extra: []Rule{long}},
+		want: [][2]string{{"10/30 9:40 AM", "10/30 1:30 PM"}},
+	}, {
+		name: "extra rule fails",
+		opts: Options{Direction: &rising, Extra: []Rule{long}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got [][2]string
+			for _, gt := range GoodTimes2(testDay, tc.opts) {
+				got = append(got, [2]string{
+					gt.Time.Truncate(time.Minute).Format("01/02 3:04 PM"),
+					gt.Time.Add(gt.Duration).Format("01/02 3:04 PM"),
+				})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("good times (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleReasons(t *testing.T) {
+	long := RuleFunc(func(c Conditions, w interval.Interval) (bool, []Reason) {
+		return true, []Reason{Note("it's long")}
+	})
+	got := FindGoodTimes(testDay,
+		TideTrend{},
+		Daylight{Twilight: time.Hour},
+		TideRange{Low: -1000, High: 1},
+		long)
+	if len(got) != 1 {
+		t.Fatalf("FindGoodTimes = %v, wanted one good time", got)
+	}
+	if end, want := got[0].Time.Add(got[0].Duration), date("10/30 2:00 PM"); !end.Equal(want) {
+		t.Errorf("good time ends at %v, wanted %v", end, want)
+	}
+
+	// Reasons follow the order of the rules.
+	want := []string{
+		"tide is slack",
+		"tide is 1.0ft at 9:40 AM",
+		"tide is -1.0ft at 12:00 PM",
+		"tide is 0.6ft at 2:00 PM",
+		"it's long",
+	}
+	if diff := cmp.Diff(want, reasonStrings(got[0].Reasons)); diff != "" {
+		t.Errorf("reasons (-want,+got):\n%s", diff)
+	}
+}
+
+func TestDayOfWeekEvaluate(t *testing.T) {
+	day := date("10/30 12:00 PM")
+	rule := DayOfWeek{day.Weekday()}
+	for _, tc := range []struct {
+		name       string
+		start, end string
+		want       bool
+	}{
+		{"within the day", "10/30 9:00 AM", "10/30 5:00 PM", true},
+		{"into the next day", "10/30 9:00 PM", "10/31 2:00 AM", false},
+		{"the day before", "10/29 9:00 AM", "10/29 5:00 PM", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := interval.Interval{Start: date(tc.start), End: date(tc.end)}
+			if got, _ := rule.Evaluate(testDay, w); got != tc.want {
+				t.Errorf("Evaluate(%s-%s) = %t, wanted %t", tc.start, tc.end, got, tc.want)
+			}
+		})
+	}
+}
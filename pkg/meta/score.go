@@ -0,0 +1,105 @@
+package meta
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+)
+
+const (
+	// bestLowTide is the tide height, in feet, at or below which a good time
+	// gets full marks for a low tide. It gets none at tideThresh.
+	bestLowTide = -1.0
+	// bestDuration is the length of a good time that gets full marks.
+	bestDuration = 3 * time.Hour
+)
+
+// A Factor rates one aspect of a good time.
+type Factor struct {
+	Name string
+	// Weight is the importance of the factor relative to the others.
+	Weight float64
+	// Rate returns how well the window does, from 0 to 1.
+	Rate func(c Conditions, w interval.Interval) float64
+}
+
+// DefaultFactors rate how low the tide goes, how long the window lasts, how
+// much of it is in full daylight, and whether it is on a weekend.
+var DefaultFactors = []Factor{
+	{Name: "low tide", Weight: 4, Rate: rateLowTide},
+	{Name: "duration", Weight: 3, Rate: rateDuration},
+	{Name: "light", Weight: 2, Rate: rateLight},
+	{Name: "weekend", Weight: 1, Rate: rateWeekend},
+}
+
+// Score rates the window from 0 to 100 as the weighted average of factors.
+func Score(c Conditions, w interval.Interval, factors []Factor) int {
+	var total, weights float64
+	for _, f := range factors {
+		total += f.Weight * clamp(f.Rate(c, w))
+		weights += f.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return int(math.Round(100 * total / weights))
+}
+
+// SortByScore sorts good times from the highest score to the lowest. Good times
+// with equal scores stay in order.
+func SortByScore(gts []GoodTime) {
+	sort.SliceStable(gts, func(i, j int) bool {
+		return gts[i].Score > gts[j].Score
+	})
+}
+
+// FilterByScore returns the good times that score at least min.
+func FilterByScore(gts []GoodTime, min int) []GoodTime {
+	result := []GoodTime{}
+	for _, gt := range gts {
+		if gt.Score >= min {
+			result = append(result, gt)
+		}
+	}
+	return result
+}
+
+func rateLowTide(c Conditions, w interval.Interval) float64 {
+	_, low := c.spline().Min(w.Start, w.End)
+	if math.IsNaN(low) {
+		return 0
+	}
+	return (tideThresh - low) / (tideThresh - bestLowTide)
+}
+
+func rateDuration(c Conditions, w interval.Interval) float64 {
+	return float64(w.Duration()) / float64(bestDuration)
+}
+
+// rateLight is the fraction of the window between sunrise and sunset.
+func rateLight(c Conditions, w interval.Interval) float64 {
+	daylight := Daylight{}.Allowed(c)
+	if w.Empty() {
+		if daylight.Contains(w.Start) {
+			return 1
+		}
+		return 0
+	}
+	lit := daylight.Intersect(interval.New(w)).Duration()
+	return float64(lit) / float64(w.Duration())
+}
+
+func rateWeekend(c Conditions, w interval.Interval) float64 {
+	switch w.Start.Weekday() {
+	case time.Saturday, time.Sunday:
+		return 1
+	}
+	return 0
+}
+
+// clamp limits a rating to between 0 and 1.
+func clamp(rating float64) float64 {
+	return math.Max(0, math.Min(1, rating))
+}
@@ -0,0 +1,89 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/surfdash/pkg/interval"
+)
+
+func TestScore(t *testing.T) {
+	c := testDay
+	for _, tc := range []struct {
+		name       string
+		start, end string
+		factor     func(Conditions, interval.Interval) float64
+		want       int
+	}{
+		{"lowest tide", "10/30 11:00 AM", "10/30 1:00 PM", rateLowTide, 100},
+		{"tide at the threshold", "10/30 6:00 AM", "10/30 6:00 AM", rateLowTide, 0},
+		{"long enough", "10/30 9:00 AM", "10/30 12:00 PM", rateDuration, 100},
+		{"half as long", "10/30 9:00 AM", "10/30 10:30 AM", rateDuration, 50},
+		{"all in daylight", "10/30 9:00 AM", "10/30 10:00 AM", rateLight, 100},
+		{"half after sunset", "10/30 12:30 PM", "10/30 1:30 PM", rateLight, 50},
+		{"instant at night", "10/30 3:00 PM", "10/30 3:00 PM", rateLight, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := interval.Interval{Start: date(tc.start), End: date(tc.end)}
+			got := Score(c, w, []Factor{{Name: tc.name, Weight: 1, Rate: tc.factor}})
+			if got != tc.want {
+				t.Errorf("Score(%s-%s) = %d, wanted %d", tc.start, tc.end, got, tc.want)
+			}
+		})
+	}
+
+	w := interval.Interval{Start: date("10/30 9:00 AM"), End: date("10/30 10:00 AM")}
+	if got := Score(c, w, nil); got != 0 {
+		t.Errorf("Score with no factors = %d, wanted 0", got)
+	}
+	weighted := []Factor{
+		{Name: "always", Weight: 3, Rate: func(Conditions, interval.Interval) float64 { return 2 }},
+		{Name: "never", Weight: 1, Rate: func(Conditions, interval.Interval) float64 { return -1 }},
+	}
+	if got := Score(c, w, weighted); got != 75 {
+		t.Errorf("Score with weighted factors = %d, wanted 75", got)
+	}
+}
+
+func TestGoodTimes2Score(t *testing.T) {
+	got := GoodTimes2(testDay, Options{})
+	if len(got) != 1 {
+		t.Fatalf("GoodTimes2 = %v, wanted one good time", got)
+	}
+	if got[0].Score <= 0 || got[0].Score > 100 {
+		t.Errorf("good time scored %d, wanted between 0 and 100", got[0].Score)
+	}
+
+	perfect := Options{Factors: []Factor{{Name: "perfect", Weight: 1, Rate: func(Conditions, interval.Interval) float64 { return 1 }}}}
+	if got := GoodTimes2(testDay, perfect); len(got) != 1 || got[0].Score != 100 {
+		t.Errorf("GoodTimes2 with a perfect factor = %v, wanted a score of 100", got)
+	}
+}
+
+func TestSortAndFilterByScore(t *testing.T) {
+	at := func(hour int) time.Time { return date("10/30 12:00 AM").Add(time.Duration(hour) * time.Hour) }
+	gts := []GoodTime{
+		{Time: at(1), Score: 40},
+		{Time: at(2), Score: 90},
+		{Time: at(3), Score: 40},
+		{Time: at(4), Score: 70},
+	}
+
+	SortByScore(gts)
+	var order []int
+	for _, gt := range gts {
+		order = append(order, gt.Time.Hour())
+	}
+	if diff := cmp.Diff([]int{2, 4, 1, 3}, order); diff != "" {
+		t.Errorf("SortByScore order (-want,+got):\n%s", diff)
+	}
+
+	if got := FilterByScore(gts, 70); len(got) != 2 {
+		t.Errorf("FilterByScore(70) = %v, wanted two good times", got)
+	}
+	if got := FilterByScore(gts, 100); len(got) != 0 {
+		t.Errorf("FilterByScore(100) = %v, wanted nothing", got)
+	}
+}
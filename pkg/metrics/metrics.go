@@ -26,12 +26,21 @@ var (
 		},
 		[]string{"userid"},
 	)
+	renderFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "render_failures",
+			Subsystem: "surfdash",
+			Help:      "Total count of charts that failed to render, by chart.",
+		},
+		[]string{"chart"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(
 		requestLatency,
 		userRequests,
+		renderFailures,
 	)
 }
 
@@ -52,6 +61,12 @@ func ObserveUserRequest(userid any) {
 	}).Inc()
 }
 
+func ObserveRenderFailure(chart string) {
+	renderFailures.With(prometheus.Labels{
+		"chart": chart,
+	}).Inc()
+}
+
 func LatencyHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t := time.Now()
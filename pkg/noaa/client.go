@@ -0,0 +1,17 @@
+package noaa
+
+// Client fetches tide predictions.
+type Client interface {
+	GetPredictions(q *PredictionQuery) (Predictions, error)
+}
+
+// ClientFunc adapts a function to a Client.
+type ClientFunc func(q *PredictionQuery) (Predictions, error)
+
+// GetPredictions calls f.
+func (f ClientFunc) GetPredictions(q *PredictionQuery) (Predictions, error) {
+	return f(q)
+}
+
+// API is the Client that queries NOAA with GetPredictions.
+var API Client = ClientFunc(GetPredictions)
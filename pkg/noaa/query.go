@@ -68,6 +68,11 @@ func GetPredictions(q *PredictionQuery) (Predictions, error) {
 	if !inCache {
 		qcache.Set(addr, body)
 	}
+	if q.Location != nil {
+		for i := range result.Predictions {
+			result.Predictions[i].Time = Time(result.Predictions[i].T().In(q.Location))
+		}
+	}
 	return result.Predictions, nil
 }
 
@@ -79,18 +84,26 @@ func (q *PredictionQuery) url() *url.URL {
 
 func (q *PredictionQuery) build() url.Values {
 	vals := make(url.Values)
-	vals.Add("begin_date", q.Start.Format(QUERY_TIME_FMT))
-	vals.Add("end_date", q.Start.Add(q.Duration).Format(QUERY_TIME_FMT))
+	vals.Add("begin_date", q.Start.UTC().Format(QUERY_TIME_FMT))
+	vals.Add("end_date", q.Start.Add(q.Duration).UTC().Format(QUERY_TIME_FMT))
 	vals.Add("station", fmt.Sprintf("%d", q.Station))
 	vals.Add("product", "predictions")
 	vals.Add("datum", "MLLW")
-	vals.Add("time_zone", "lst_ldt")
-	vals.Add("interval", "hilo")
+	// Ask for GMT, since the local time of the station need not be ours.
+	vals.Add("time_zone", "gmt")
+	vals.Add("interval", string(q.interval()))
 	vals.Add("units", "english")
 	vals.Add("format", "json")
 	return vals
 }
 
+func (q *PredictionQuery) interval() Interval {
+	if q.Interval == "" {
+		return HiLo
+	}
+	return q.Interval
+}
+
 func decodeResponse(resp io.Reader) (*NOAAResult, error) {
 	var result NOAAResult
 	if err := json.NewDecoder(resp).Decode(&result); err != nil {
@@ -2,20 +2,78 @@ package noaa
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
 
 func TestQueryURL(t *testing.T) {
 	in := PredictionQuery{
-		Start:    time.Date(2020, time.January, 5, 0, 0, 0, 0, time.Local),
+		Start:    time.Date(2020, time.January, 5, 0, 0, 0, 0, time.UTC),
 		Duration: 1 * time.Hour,
 		Station:  SantaCruz,
 	}
-	want := fmt.Sprintf("https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?begin_date=20200105&datum=MLLW&end_date=20200105&format=json&interval=hilo&product=predictions&station=%d&time_zone=lst_ldt&units=english", SantaCruz)
+	want := fmt.Sprintf("https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?begin_date=20200105&datum=MLLW&end_date=20200105&format=json&interval=hilo&product=predictions&station=%d&time_zone=gmt&units=english", SantaCruz)
 	got := in.url().String()
 	if want != got {
 		t.Errorf("got  %q", got)
 		t.Errorf("want %q", want)
 	}
 }
+
+func TestQueryURLInterval(t *testing.T) {
+	in := PredictionQuery{
+		Start:    time.Date(2020, time.January, 5, 0, 0, 0, 0, time.UTC),
+		Duration: 1 * time.Hour,
+		Station:  SantaCruz,
+		Interval: SixMinute,
+	}
+	want := fmt.Sprintf("https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?begin_date=20200105&datum=MLLW&end_date=20200105&format=json&interval=6&product=predictions&station=%d&time_zone=gmt&units=english", SantaCruz)
+	got := in.url().String()
+	if want != got {
+		t.Errorf("got  %q", got)
+		t.Errorf("want %q", want)
+	}
+}
+
+func TestGetPredictionsLocation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tz := r.FormValue("time_zone"); tz != "gmt" {
+			t.Errorf("got time_zone %q, wanted gmt", tz)
+		}
+		fmt.Fprint(w, `{"predictions":[{"t":"2021-06-01 12:00", "v":"-0.5", "type":"L"}]}`)
+	}))
+	defer srv.Close()
+	defer func(u url.URL) { NOAA_URL = u }(NOAA_URL)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NOAA_URL.Scheme, NOAA_URL.Host = u.Scheme, u.Host
+
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds, err := GetPredictions(&PredictionQuery{
+		Start:    time.Date(2021, time.June, 1, 0, 0, 0, 0, honolulu),
+		Duration: 24 * time.Hour,
+		Station:  1612340,
+		Location: honolulu,
+	})
+	if err != nil {
+		t.Fatalf("GetPredictions failed: %v", err)
+	}
+	if len(preds) != 1 {
+		t.Fatalf("got %d predictions, wanted 1", len(preds))
+	}
+	got := preds[0].T()
+	if want := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got time %v, wanted %v", got, want)
+	}
+	if got.Location() != honolulu || got.Hour() != 2 {
+		t.Errorf("got time %v, wanted 2AM in Honolulu", got)
+	}
+}
@@ -0,0 +1,56 @@
+package noaa
+
+import (
+	"sort"
+	"time"
+)
+
+// Search returns the index of the first prediction that occurs at or after t.
+// If every prediction occurs before t, it returns len(preds).
+func (preds Predictions) Search(t time.Time) int {
+	return sort.Search(len(preds), func(i int) bool {
+		return !preds[i].T().Before(t)
+	})
+}
+
+// IndexAtOrBefore returns the index of the last prediction that occurs at or
+// before t, or -1 if there is none.
+func (preds Predictions) IndexAtOrBefore(t time.Time) int {
+	i := preds.Search(t)
+	if i < len(preds) && preds[i].T().Equal(t) {
+		return i
+	}
+	return i - 1
+}
+
+// Next returns the first prediction of the given tide type that occurs at or
+// after t.
+func (preds Predictions) Next(t time.Time, kind Tide) (Prediction, bool) {
+	for i := preds.Search(t); i < len(preds); i++ {
+		if preds[i].Type == kind {
+			return preds[i], true
+		}
+	}
+	return Prediction{}, false
+}
+
+// Prev returns the last prediction of the given tide type that occurs strictly
+// before t.
+func (preds Predictions) Prev(t time.Time, kind Tide) (Prediction, bool) {
+	for i := preds.Search(t) - 1; i >= 0; i-- {
+		if preds[i].Type == kind {
+			return preds[i], true
+		}
+	}
+	return Prediction{}, false
+}
+
+// Between returns the predictions that occur in the half open range [start,
+// end). The result shares memory with preds.
+func (preds Predictions) Between(start, end time.Time) Predictions {
+	i, j := preds.Search(start), preds.Search(end)
+	if j < i {
+		return preds[i:i]
+	}
+	return preds[i:j]
+}
@@ -0,0 +1,81 @@
+package noaa
+
+import (
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2021, time.April, 3, hour, minute, 0, 0, time.Local)
+}
+
+var testPreds = Predictions{
+	{Time: Time(at(1, 0)), Height: 4, Type: HighTide},
+	{Time: Time(at(7, 0)), Height: 0, Type: LowTide},
+	{Time: Time(at(13, 0)), Height: 5, Type: HighTide},
+	{Time: Time(at(19, 0)), Height: 1, Type: LowTide},
+}
+
+func TestSearch(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		t             time.Time
+		search, atOrB int
+	}{
+		{"before all", at(0, 0), 0, -1},
+		{"exactly first", at(1, 0), 0, 0},
+		{"between", at(10, 0), 2, 1},
+		{"exactly last", at(19, 0), 3, 3},
+		{"after all", at(23, 0), 4, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := testPreds.Search(tc.t); got != tc.search {
+				t.Errorf("Search(%v) = %d, wanted %d", tc.t, got, tc.search)
+			}
+			if got := testPreds.IndexAtOrBefore(tc.t); got != tc.atOrB {
+				t.Errorf("IndexAtOrBefore(%v) = %d, wanted %d", tc.t, got, tc.atOrB)
+			}
+		})
+	}
+
+	if got := (Predictions{}).IndexAtOrBefore(at(0, 0)); got != -1 {
+		t.Errorf("IndexAtOrBefore on empty predictions = %d, wanted -1", got)
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	next, ok := testPreds.Next(at(7, 0), LowTide)
+	if !ok || !next.T().Equal(at(7, 0)) {
+		t.Errorf("Next low at a low tide = %v, %t; wanted that tide", next, ok)
+	}
+	prev, ok := testPreds.Prev(at(7, 0), LowTide)
+	if ok {
+		t.Errorf("Prev low at the first low tide = %v; wanted none", prev)
+	}
+	prev, ok = testPreds.Prev(at(23, 0), HighTide)
+	if !ok || !prev.T().Equal(at(13, 0)) {
+		t.Errorf("Prev high after all data = %v, %t; wanted 13:00", prev, ok)
+	}
+	if next, ok := testPreds.Next(at(20, 0), LowTide); ok {
+		t.Errorf("Next low after all data = %v; wanted none", next)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		want       int
+	}{
+		{"half open range", at(1, 0), at(13, 0), 2},
+		{"empty range", at(10, 0), at(10, 0), 0},
+		{"inverted range", at(20, 0), at(0, 0), 0},
+		{"everything", at(0, 0), at(23, 59), 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := testPreds.Between(tc.start, tc.end); len(got) != tc.want {
+				t.Errorf("Between(%v, %v) has %d predictions, wanted %d", tc.start, tc.end, len(got), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package splines
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// The JSON encoding of a Spline is shared with static/tide.js, which evaluates
+// it in the browser. Both must agree on every field; see
+// testdata/golden_spline.json.
+//
+//	{
+//	  "version": 1,
+//	  "units": "ft",
+//	  "time_base": "unix",
+//	  "curves": [
+//	    {"type": "cubic", "start": 0, "end": 3600, "a": 0, "b": 0, "c": 0, "d": 0},
+//	    {"type": "cosine", "start": 3600, "end": 7200, "mid": 0, "amp": 0}
+//	  ]
+//	}
+//
+// Start and end are Unix seconds. Each curve is evaluated at x, the number of
+// seconds since its start:
+//
+//	cubic:  a x^3 + b x^2 + c x + d
+//	cosine: mid + amp cos(pi x / (end - start))
+const (
+	encodingVersion  = 1
+	encodingUnits    = "ft"
+	encodingTimeBase = "unix"
+)
+
+// envelope is the encoded form of a Spline.
+type envelope struct {
+	Version  int     `json:"version"`
+	Units    string  `json:"units"`
+	TimeBase string  `json:"time_base"`
+	Curves   []Curve `json:"curves"`
+}
+
+func (s Spline) MarshalJSON() ([]byte, error) {
+	curves := []Curve(s)
+	if curves == nil {
+		curves = []Curve{}
+	}
+	return json.Marshal(envelope{
+		Version:  encodingVersion,
+		Units:    encodingUnits,
+		TimeBase: encodingTimeBase,
+		Curves:   curves,
+	})
+}
+
+// UnmarshalJSON decodes a Spline from its envelope. For compatibility, it also
+// accepts the unversioned form, a bare list of cubic curves.
+func (s *Spline) UnmarshalJSON(buf []byte) error {
+	if trimmed := bytes.TrimSpace(buf); len(trimmed) > 0 && trimmed[0] == '[' {
+		var curves []Curve
+		if err := json.Unmarshal(trimmed, &curves); err != nil {
+			return err
+		}
+		*s = curves
+		return nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(buf, &env); err != nil {
+		return err
+	}
+	if env.Version != encodingVersion {
+		return fmt.Errorf("unsupported spline version %d", env.Version)
+	}
+	if env.Units != encodingUnits {
+		return fmt.Errorf("unsupported spline units %q", env.Units)
+	}
+	if env.TimeBase != encodingTimeBase {
+		return fmt.Errorf("unsupported spline time base %q", env.TimeBase)
+	}
+	*s = env.Curves
+	return nil
+}
+
+func (c Curve) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch sh := c.shape.(type) {
+	case cubic:
+		_, err = fmt.Fprintf(&buf, `{"type":"cubic","start":%d,"end":%d,"a":%g,"b":%g,"c":%g,"d":%g}`,
+			c.Start.Unix(), c.End.Unix(),
+			sh.a, sh.b, sh.c, sh.d)
+	case cosine:
+		_, err = fmt.Fprintf(&buf, `{"type":"cosine","start":%d,"end":%d,"mid":%g,"amp":%g}`,
+			c.Start.Unix(), c.End.Unix(),
+			sh.mid, sh.amp)
+	default:
+		err = fmt.Errorf("cannot encode curve of type %T", c.shape)
+	}
+	return buf.Bytes(), err
+}
+
+func (c *Curve) UnmarshalJSON(buf []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return err
+	}
+	var typ string
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return fmt.Errorf("curve type %s not a string: %w", raw, err)
+		}
+	} else {
+		// Curves were cubic before they had a type.
+		typ = "cubic"
+	}
+
+	number := func(name string, dst any) error {
+		raw, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("%s curve missing %q", typ, name)
+		}
+		if err := json.Unmarshal(raw, dst); err != nil {
+			return fmt.Errorf("%s curve field %q: %w", typ, name, err)
+		}
+		return nil
+	}
+
+	var start, end int64
+	if err := number("start", &start); err != nil {
+		return err
+	}
+	if err := number("end", &end); err != nil {
+		return err
+	}
+	c.Start, c.End = time.Unix(start, 0), time.Unix(end, 0)
+
+	switch typ {
+	case "cubic":
+		var p cubic
+		for name, dst := range map[string]*float64{"a": &p.a, "b": &p.b, "c": &p.c, "d": &p.d} {
+			if err := number(name, dst); err != nil {
+				return err
+			}
+		}
+		c.shape = p
+	case "cosine":
+		p := cosine{width: float64(end - start)}
+		for name, dst := range map[string]*float64{"mid": &p.mid, "amp": &p.amp} {
+			if err := number(name, dst); err != nil {
+				return err
+			}
+		}
+		c.shape = p
+	default:
+		return fmt.Errorf("unknown curve type %q", typ)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package splines
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+const goldenPath = "testdata/golden_spline.json"
+
+// golden is a spline with the heights it must evaluate to, shared by the Go
+// and JavaScript implementations.
+type golden struct {
+	Spline  json.RawMessage `json:"spline"`
+	Samples [][2]float64    `json:"samples"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, m := range []Model{Cubic, Cosine, Monotone} {
+		t.Run(m.Name(), func(t *testing.T) {
+			want := m.Interpolate(testPreds)
+			blob, err := json.Marshal(want)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			var got Spline
+			if err := json.Unmarshal(blob, &got); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", blob, err)
+			}
+			for at := tstart; !at.After(tstart.Add(12 * time.Hour)); at = at.Add(7 * time.Minute) {
+				if w, g := want.Eval(at), got.Eval(at); w != g {
+					t.Errorf("at %v got %v, wanted %v", at, g, w)
+				}
+			}
+			again, err := json.Marshal(got)
+			if err != nil || !bytes.Equal(again, blob) {
+				t.Errorf("re-encoding differs:\n%s\n%s", blob, again)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for name, in := range map[string]string{
+		"future version": `{"version":2,"units":"ft","time_base":"unix","curves":[]}`,
+		"metric":         `{"version":1,"units":"m","time_base":"unix","curves":[]}`,
+		"unknown type":   `{"version":1,"units":"ft","time_base":"unix","curves":[{"type":"spiral","start":0,"end":1}]}`,
+		"missing field":  `{"version":1,"units":"ft","time_base":"unix","curves":[{"type":"cosine","start":0,"end":1,"mid":1}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var s Spline
+			if err := json.Unmarshal([]byte(in), &s); err == nil {
+				t.Errorf("unmarshal unexpectedly succeeded: %v", s)
+			}
+		})
+	}
+}
+
+func TestUnmarshalLegacy(t *testing.T) {
+	var s Spline
+	if err := json.Unmarshal([]byte(`[{"start":0,"end":10,"a":0,"b":0,"c":1,"d":2}]`), &s); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if got := s.Eval(time.Unix(5, 0)); got != 7 {
+		t.Errorf("legacy curve at 5s = %v, wanted 7", got)
+	}
+}
+
+// goldenSpline mixes cubic and cosine curves over a day of tides.
+func goldenSpline() Spline {
+	preds := append(noaa.Predictions{}, testPreds...)
+	preds = append(preds,
+		noaa.Prediction{Time: noaa.Time(tstart.Add(18*time.Hour + 25*time.Minute)), Height: 0.483},
+		noaa.Prediction{Time: noaa.Time(tstart.Add(24*time.Hour + 52*time.Minute)), Height: 4.161})
+	return append(Cubic.Interpolate(preds[:3]), Cosine.Interpolate(preds[2:])...)
+}
+
+func readGolden(t *testing.T) golden {
+	t.Helper()
+	if *update {
+		spl := goldenSpline()
+		encoded, err := json.Marshal(spl)
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		g := golden{Spline: encoded}
+		for at := spl[0].Start; !at.After(spl[len(spl)-1].End); at = at.Add(20 * time.Minute) {
+			g.Samples = append(g.Samples, [2]float64{float64(at.Unix()), spl.Eval(at)})
+		}
+		blob, err := json.MarshalIndent(g, "", "\t")
+		if err != nil {
+			t.Fatalf("failed to marshal golden: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, append(blob, '\n'), 0644); err != nil {
+			t.Fatalf("failed to write golden: %v", err)
+		}
+	}
+
+	blob, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden: %v", err)
+	}
+	var g golden
+	if err := json.Unmarshal(blob, &g); err != nil {
+		t.Fatalf("failed to parse golden: %v", err)
+	}
+	return g
+}
+
+func TestGoldenSpline(t *testing.T) {
+	g := readGolden(t)
+	var spl Spline
+	if err := json.Unmarshal(g.Spline, &spl); err != nil {
+		t.Fatalf("failed to unmarshal golden spline: %v", err)
+	}
+	for _, sample := range g.Samples {
+		at := time.Unix(int64(sample[0]), 0)
+		if got := spl.Eval(at); math.Abs(got-sample[1]) > 1e-9 {
+			t.Errorf("at %v got %v, wanted %v", at, got, sample[1])
+		}
+	}
+
+	// The models must still produce the golden spline.
+	blob, err := json.Marshal(goldenSpline())
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var want bytes.Buffer
+	if err := json.Compact(&want, g.Spline); err != nil {
+		t.Fatalf("failed to compact golden: %v", err)
+	}
+	if !bytes.Equal(blob, want.Bytes()) {
+		t.Errorf("encoding changed; run go test -update if intended:\n got %s\nwant %s", blob, want.Bytes())
+	}
+}
+
+func TestGoldenSplineJS(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	g := readGolden(t)
+
+	out, err := exec.Command(node, filepath.Join("testdata", "eval_tide.js")).Output()
+	if err != nil {
+		t.Fatalf("failed to evaluate with tide.js: %v", err)
+	}
+	var heights []float64
+	if err := json.Unmarshal(out, &heights); err != nil {
+		t.Fatalf("failed to parse tide.js output %q: %v", out, err)
+	}
+	if len(heights) != len(g.Samples) {
+		t.Fatalf("tide.js evaluated %d samples, wanted %d", len(heights), len(g.Samples))
+	}
+	for i, sample := range g.Samples {
+		if math.Abs(heights[i]-sample[1]) > 1e-9 {
+			t.Errorf("at %v tide.js got %v, Go got %v", time.Unix(int64(sample[0]), 0), heights[i], sample[1])
+		}
+	}
+}
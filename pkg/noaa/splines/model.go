@@ -0,0 +1,151 @@
+package splines
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+// A Model interpolates a continuous Spline from discrete tide predictions.
+type Model interface {
+	// Name identifies the model.
+	Name() string
+	// Interval is the kind of predictions the model expects to interpolate.
+	Interval() noaa.Interval
+	// Interpolate links the predictions into a Spline. It returns nil if
+	// there are fewer than two predictions.
+	Interpolate(preds noaa.Predictions) Spline
+}
+
+var (
+	// Cubic links high and low tides with cubics that are flat at each
+	// prediction.
+	Cubic Model = cubicModel{}
+
+	// Cosine links high and low tides with half cosine waves, which follow
+	// the rule of twelfths.
+	Cosine Model = cosineModel{}
+
+	// Monotone links dense predictions with piecewise cubics that never
+	// overshoot the data between predictions.
+	Monotone Model = monotoneModel{}
+)
+
+// stationModels holds the preferred model of each station. Stations that are
+// not listed use Cubic.
+var stationModels = map[noaa.Station]Model{
+	noaa.SantaCruz: Cubic,
+}
+
+// ModelFor returns the model to use for a station.
+func ModelFor(station noaa.Station) Model {
+	if m, ok := stationModels[station]; ok {
+		return m
+	}
+	return Cubic
+}
+
+// ModelByName returns the model with the given name.
+func ModelByName(name string) (Model, error) {
+	for _, m := range []Model{Cubic, Cosine, Monotone} {
+		if m.Name() == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown tide model %q", name)
+}
+
+type cubicModel struct{}
+
+func (cubicModel) Name() string            { return "cubic" }
+func (cubicModel) Interval() noaa.Interval { return noaa.HiLo }
+
+func (cubicModel) Interpolate(preds noaa.Predictions) Spline {
+	return linkPairs(preds, curveBetween)
+}
+
+type cosineModel struct{}
+
+func (cosineModel) Name() string            { return "cosine" }
+func (cosineModel) Interval() noaa.Interval { return noaa.HiLo }
+
+func (cosineModel) Interpolate(preds noaa.Predictions) Spline {
+	return linkPairs(preds, func(time1 time.Time, h1 float64, time2 time.Time, h2 float64) Curve {
+		return Curve{
+			Start: time1,
+			End:   time2,
+			shape: cosine{
+				mid:   (h1 + h2) / 2,
+				amp:   (h1 - h2) / 2,
+				width: xrel(time1, time2),
+			},
+		}
+	})
+}
+
+// linkPairs creates a curve between each consecutive pair of predictions.
+func linkPairs(preds noaa.Predictions, link func(time.Time, float64, time.Time, float64) Curve) Spline {
+	if len(preds) < 2 {
+		return nil
+	}
+
+	curves := make([]Curve, len(preds)-1)
+	for i := 0; i < len(preds)-1; i++ {
+		curves[i] = link(
+			time.Time(preds[i].Time),
+			float64(preds[i].Height),
+			time.Time(preds[i+1].Time),
+			float64(preds[i+1].Height))
+	}
+	return curves
+}
+
+type monotoneModel struct{}
+
+func (monotoneModel) Name() string            { return "monotone" }
+func (monotoneModel) Interval() noaa.Interval { return noaa.SixMinute }
+
+// Interpolate fits a monotone piecewise cubic Hermite interpolant (Fritsch and
+// Carlson) to the predictions.
+func (monotoneModel) Interpolate(preds noaa.Predictions) Spline {
+	n := len(preds)
+	if n < 2 {
+		return nil
+	}
+
+	widths := make([]float64, n-1)
+	secants := make([]float64, n-1)
+	for i := range widths {
+		widths[i] = xrel(preds[i].T(), preds[i+1].T())
+		secants[i] = float64(preds[i+1].Height-preds[i].Height) / widths[i]
+	}
+
+	// The slope at each prediction is zero at local extremes and otherwise a
+	// weighted harmonic mean of the neighboring secants, which keeps each
+	// piece monotonic.
+	slopes := make([]float64, n)
+	slopes[0], slopes[n-1] = secants[0], secants[n-2]
+	for i := 1; i < n-1; i++ {
+		s0, s1 := secants[i-1], secants[i]
+		if s0*s1 <= 0 {
+			continue
+		}
+		w0 := 2*widths[i] + widths[i-1]
+		w1 := widths[i] + 2*widths[i-1]
+		slopes[i] = (w0 + w1) / (w0/s0 + w1/s1)
+	}
+
+	curves := make([]Curve, n-1)
+	for i := range curves {
+		curves[i] = Curve{
+			Start: preds[i].T(),
+			End:   preds[i+1].T(),
+			shape: hermite(
+				float64(preds[i].Height), slopes[i],
+				float64(preds[i+1].Height), slopes[i+1],
+				widths[i]),
+		}
+	}
+	return curves
+}
@@ -0,0 +1,144 @@
+package splines
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+func loadFixture(t *testing.T, name string) noaa.Predictions {
+	t.Helper()
+	blob, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var result noaa.NOAAResult
+	if err := json.Unmarshal(blob, &result); err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", name, err)
+	}
+	return result.Predictions
+}
+
+// modelError measures how far spl strays from the truth, in feet.
+func modelError(spl Spline, truth noaa.Predictions) (rms, max float64) {
+	var sumSquares float64
+	var n int
+	for _, p := range truth {
+		h := spl.Eval(p.T())
+		if math.IsNaN(h) {
+			continue
+		}
+		diff := math.Abs(h - float64(p.Height))
+		sumSquares += diff * diff
+		max = math.Max(max, diff)
+		n++
+	}
+	return math.Sqrt(sumSquares / float64(n)), max
+}
+
+// every keeps one in n predictions.
+func every(preds noaa.Predictions, n int) noaa.Predictions {
+	var result noaa.Predictions
+	for i := 0; i < len(preds); i += n {
+		result = append(result, preds[i])
+	}
+	return result
+}
+
+func TestModelError(t *testing.T) {
+	hilo := loadFixture(t, "santacruz_hilo.json")
+	dense := loadFixture(t, "santacruz_6min.json")
+
+	for _, tc := range []struct {
+		model Model
+		input noaa.Predictions
+		// Bounds on the error in feet, to catch regressions.
+		maxRMS, maxErr float64
+	}{
+		{Cubic, hilo, 0.2, 0.5},
+		{Cosine, hilo, 0.2, 0.5},
+		// Interpolating the dense predictions would be exact, so fit hourly
+		// predictions and measure against those in between.
+		{Monotone, every(dense, 10), 0.05, 0.15},
+	} {
+		t.Run(tc.model.Name(), func(t *testing.T) {
+			spl := tc.model.Interpolate(tc.input)
+			rms, max := modelError(spl, dense)
+			t.Logf("%s: rms error %.3fft, max error %.3fft", tc.model.Name(), rms, max)
+			if rms > tc.maxRMS {
+				t.Errorf("rms error %.3fft exceeds %.3fft", rms, tc.maxRMS)
+			}
+			if max > tc.maxErr {
+				t.Errorf("max error %.3fft exceeds %.3fft", max, tc.maxErr)
+			}
+		})
+	}
+}
+
+func TestMonotoneDoesNotOvershoot(t *testing.T) {
+	// A step in the data makes ordinary cubic splines ring.
+	preds := noaa.Predictions{
+		{Time: noaa.Time(tstart), Height: 0},
+		{Time: noaa.Time(tstart.Add(time.Hour)), Height: 0},
+		{Time: noaa.Time(tstart.Add(2 * time.Hour)), Height: 5},
+		{Time: noaa.Time(tstart.Add(3 * time.Hour)), Height: 5},
+	}
+	spl := Monotone.Interpolate(preds)
+	for t0 := tstart; !t0.After(tstart.Add(3 * time.Hour)); t0 = t0.Add(time.Minute) {
+		if h := spl.Eval(t0); h < 0 || h > 5 {
+			t.Fatalf("tide at %v is %f, outside the data", t0, h)
+		}
+	}
+}
+
+func TestModelsInterpolate(t *testing.T) {
+	for _, m := range []Model{Cubic, Cosine, Monotone} {
+		t.Run(m.Name(), func(t *testing.T) {
+			spl := m.Interpolate(testPreds)
+			for _, p := range testPreds {
+				if h := spl.Eval(p.T()); math.Abs(h-float64(p.Height)) > 0.001 {
+					t.Errorf("tide at prediction %v is %f, wanted %f", p.T(), h, p.Height)
+				}
+			}
+			if got := m.Interpolate(testPreds[:1]); got != nil {
+				t.Errorf("Interpolate with one prediction = %v, wanted nil", got)
+			}
+
+			byName, err := ModelByName(m.Name())
+			if err != nil || byName != m {
+				t.Errorf("ModelByName(%q) = %v, %v", m.Name(), byName, err)
+			}
+		})
+	}
+
+	if _, err := ModelByName("quintic"); err == nil {
+		t.Errorf("ModelByName of an unknown model unexpectedly succeeded")
+	}
+}
+
+func TestCosineCrossings(t *testing.T) {
+	spl := Cosine.Interpolate(testPreds)
+
+	// Halfway between high and low, a cosine is exactly halfway in time too.
+	got := spl.Crossings(2)
+	want := []time.Time{tstart.Add(3 * time.Hour), tstart.Add(9 * time.Hour)}
+	if len(got) != len(want) {
+		t.Fatalf("Crossings(2) = %v, wanted %v", got, want)
+	}
+	for i := range want {
+		if d := got[i].Sub(want[i]); d < -time.Second || d > time.Second {
+			t.Errorf("crossing %d at %v, wanted %v", i, got[i], want[i])
+		}
+	}
+
+	// A cosine is fastest halfway, at amplitude * pi / width, which is
+	// 3ft * pi / 6h.
+	if _, rate := spl.MaxRate(tstart, tstart.Add(12*time.Hour)); math.Abs(rate-math.Pi/2) > 0.001 {
+		t.Errorf("MaxRate = %f, wanted %f", rate, math.Pi/2)
+	}
+}
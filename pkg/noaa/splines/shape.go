@@ -0,0 +1,111 @@
+package splines
+
+import (
+	"math"
+)
+
+// shape is the form of a Curve between its endpoints. All x coordinates are
+// seconds since the start of the curve, and width is the length of the curve in
+// seconds.
+type shape interface {
+	// eval returns the height at x.
+	eval(x float64) float64
+	// slope returns the derivative at x in feet per second.
+	slope(x float64) float64
+	// critical returns the points strictly within (0, width) where the slope
+	// is zero, in order. Between them the shape is monotonic.
+	critical(width float64) []float64
+	// inflections returns the points strictly within (0, width) where the
+	// slope is at an extreme, in order. Between them the slope is monotonic.
+	inflections(width float64) []float64
+}
+
+// cubic is the polynomial a x^3 + b x^2 + c x + d.
+type cubic struct {
+	a, b, c, d float64
+}
+
+func (p cubic) eval(x float64) float64 {
+	return p.a*x*x*x + p.b*x*x + p.c*x + p.d
+}
+
+func (p cubic) slope(x float64) float64 {
+	return 3*p.a*x*x + 2*p.b*x + p.c
+}
+
+func (p cubic) critical(width float64) []float64 {
+	return within(width, quadraticRoots(3*p.a, 2*p.b, p.c)...)
+}
+
+func (p cubic) inflections(width float64) []float64 {
+	if p.a == 0 {
+		return nil
+	}
+	return within(width, -p.b/(3*p.a))
+}
+
+// hermite returns the cubic over [0, width] that starts at h0 with slope m0 and
+// ends at h1 with slope m1. Slopes are in feet per second.
+func hermite(h0, m0, h1, m1, width float64) cubic {
+	w2 := width * width
+	return cubic{
+		a: (2*(h0-h1) + (m0+m1)*width) / (w2 * width),
+		b: (3*(h1-h0) - (2*m0+m1)*width) / w2,
+		c: m0,
+		d: h0,
+	}
+}
+
+// cosine is half a period of a cosine wave, mid + amp cos(pi x / width). It
+// rises or falls from mid+amp to mid-amp with zero slope at each end, and
+// moves fastest in the middle, like the rule of twelfths.
+type cosine struct {
+	mid, amp, width float64
+}
+
+func (c cosine) eval(x float64) float64 {
+	return c.mid + c.amp*math.Cos(math.Pi*x/c.width)
+}
+
+func (c cosine) slope(x float64) float64 {
+	return -c.amp * math.Pi / c.width * math.Sin(math.Pi*x/c.width)
+}
+
+func (c cosine) critical(width float64) []float64 {
+	return nil
+}
+
+func (c cosine) inflections(width float64) []float64 {
+	return within(width, width/2)
+}
+
+// within filters xs to those strictly inside (0, width).
+func within(width float64, xs ...float64) []float64 {
+	var result []float64
+	for _, x := range xs {
+		if x > 0 && x < width {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+// quadraticRoots returns the real roots of qa x^2 + qb x + qc, in order.
+func quadraticRoots(qa, qb, qc float64) []float64 {
+	if qa == 0 {
+		if qb == 0 {
+			return nil
+		}
+		return []float64{-qc / qb}
+	}
+	disc := qb*qb - 4*qa*qc
+	if disc < 0 {
+		return nil
+	}
+	sq := math.Sqrt(disc)
+	r1, r2 := (-qb-sq)/(2*qa), (-qb+sq)/(2*qa)
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	return []float64{r1, r2}
+}
@@ -0,0 +1,146 @@
+package splines
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	secondsPerHour = 60 * 60
+
+	// slopeEpsilon is the smallest rate of change in feet per hour that is
+	// distinguished from zero. Shapes that are flat at their ends may not be
+	// exactly zero there due to rounding.
+	slopeEpsilon = 1e-9
+)
+
+// Slope returns the rate of change of the tide at t in feet per hour. It is
+// NaN outside the curve.
+func (c Curve) Slope(t time.Time) float64 {
+	if t.Before(c.Start) || t.After(c.End) {
+		return math.NaN()
+	}
+	return c.slopeX(xrel(c.Start, t))
+}
+
+// Slope returns the rate of change of the tide at t in feet per hour. It is
+// NaN where the spline is not defined.
+func (s Spline) Slope(t time.Time) float64 {
+	i, ok := s.find(t)
+	if !ok {
+		return math.NaN()
+	}
+	return s[i].Slope(t)
+}
+
+// SlopeCrossings returns the times within the curve where the tide changes at
+// exactly rate feet per hour, in order. The times are precise to the second.
+func (c Curve) SlopeCrossings(rate float64) []time.Time {
+	// Between inflections the slope is monotonic, so each piece reaches rate
+	// at most once.
+	width := xrel(c.Start, c.End)
+	xs := append([]float64{0}, c.shape.inflections(width)...)
+	xs = append(xs, width)
+	f := func(x float64) float64 {
+		diff := c.slopeX(x) - rate
+		if math.Abs(diff) < slopeEpsilon {
+			return 0
+		}
+		return diff
+	}
+
+	var result []time.Time
+	record := func(x float64) {
+		t := c.Start.Add(time.Duration(math.Round(x)) * time.Second)
+		if n := len(result); n > 0 && result[n-1].Equal(t) {
+			return
+		}
+		result = append(result, t)
+	}
+	for i := 0; i+1 < len(xs); i++ {
+		f0, f1 := f(xs[i]), f(xs[i+1])
+		switch {
+		case f0 == 0:
+			record(xs[i])
+		case f0*f1 < 0:
+			record(bisect(f, xs[i], xs[i+1]))
+		}
+	}
+	if f(width) == 0 {
+		record(width)
+	}
+	return result
+}
+
+// SlopeCrossings returns the times within the spline where the tide changes at
+// exactly rate feet per hour, in order. The times are precise to the second.
+func (s Spline) SlopeCrossings(rate float64) []time.Time {
+	var result []time.Time
+	for _, c := range s {
+		for _, t := range c.SlopeCrossings(rate) {
+			// Curves share their endpoints, so don't report a joint twice.
+			if n := len(result); n > 0 && !result[n-1].Before(t) {
+				continue
+			}
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// MaxRate returns the time and magnitude of the fastest change in tide within
+// [start, end], in feet per hour. If the spline is not defined anywhere in that
+// range, the rate is NaN.
+func (s Spline) MaxRate(start, end time.Time) (time.Time, float64) {
+	bestt, best := time.Time{}, math.NaN()
+	consider := func(t time.Time) {
+		if t.Before(start) || t.After(end) {
+			return
+		}
+		r := math.Abs(s.Slope(t))
+		if math.IsNaN(r) {
+			return
+		}
+		if math.IsNaN(best) || r > best {
+			bestt, best = t, r
+		}
+	}
+
+	// The extremes of the slope are at the ends of the range or at
+	// inflections.
+	consider(start)
+	consider(end)
+	for _, c := range s {
+		if c.End.Before(start) || c.Start.After(end) {
+			continue
+		}
+		consider(c.Start)
+		consider(c.End)
+		for _, x := range c.shape.inflections(xrel(c.Start, c.End)) {
+			consider(c.Start.Add(time.Duration(math.Round(x)) * time.Second))
+		}
+	}
+	return bestt, best
+}
+
+// slopeX is the derivative of the curve at x seconds after its start, in feet
+// per hour.
+func (c Curve) slopeX(x float64) float64 {
+	return c.shape.slope(x) * secondsPerHour
+}
+
+// find returns the index of the curve that contains t.
+func (s Spline) find(t time.Time) (int, bool) {
+	left, right := 0, len(s)
+	for right > left {
+		mid := left + (right-left)/2
+		if t.Before(s[mid].Start) {
+			right = mid
+		} else if t.After(s[mid].End) {
+			left = mid + 1
+		} else {
+			return mid, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package splines
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSlope(t *testing.T) {
+	spl := CurvesBetween(testPreds)
+
+	for _, tc := range []struct {
+		name string
+		at   time.Time
+		want float64
+	}{
+		{"slack at high", tstart, 0},
+		{"fastest falling", tstart.Add(3 * time.Hour), -1.5},
+		{"slack at low", tstart.Add(6 * time.Hour), 0},
+		{"fastest rising", tstart.Add(9 * time.Hour), 1.5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := spl.Slope(tc.at); math.Abs(got-tc.want) > 0.001 {
+				t.Errorf("Slope(%v) = %f, wanted %f", tc.at, got, tc.want)
+			}
+		})
+	}
+
+	if got := spl.Slope(tstart.Add(-time.Hour)); !math.IsNaN(got) {
+		t.Errorf("Slope before the spline = %f, wanted NaN", got)
+	}
+}
+
+func TestSlopeCrossings(t *testing.T) {
+	spl := CurvesBetween(testPreds)
+
+	// Only the rising curve reaches 1ft/hr, and does so twice.
+	got := spl.SlopeCrossings(1)
+	if len(got) != 2 {
+		t.Fatalf("SlopeCrossings(1) = %v, wanted two crossings", got)
+	}
+	for _, at := range got {
+		if s := spl.Slope(at); math.Abs(s-1) > 0.001 {
+			t.Errorf("slope at crossing %v is %f, wanted 1", at, s)
+		}
+	}
+
+	// The slope is zero at every prediction, and the shared joint is only
+	// reported once.
+	if got := spl.SlopeCrossings(0); len(got) != 3 {
+		t.Errorf("SlopeCrossings(0) = %v, wanted the three predictions", got)
+	}
+
+	if got := spl.SlopeCrossings(2); len(got) != 0 {
+		t.Errorf("SlopeCrossings(2) = %v, wanted none", got)
+	}
+}
+
+func TestMaxRate(t *testing.T) {
+	spl := CurvesBetween(testPreds)
+
+	at, rate := spl.MaxRate(tstart, tstart.Add(12*time.Hour))
+	if !at.Equal(tstart.Add(3*time.Hour)) || math.Abs(rate-1.5) > 0.001 {
+		t.Errorf("MaxRate = %f at %v, wanted 1.5 at 03:00", rate, at)
+	}
+
+	at, rate = spl.MaxRate(tstart.Add(5*time.Hour), tstart.Add(7*time.Hour))
+	if want := math.Abs(spl.Slope(tstart.Add(5 * time.Hour))); math.Abs(rate-want) > 0.001 {
+		t.Errorf("MaxRate around the low = %f at %v, wanted %f", rate, at, want)
+	}
+}
@@ -0,0 +1,129 @@
+package splines
+
+import (
+	"math"
+	"time"
+)
+
+// Crossings returns the times within the curve where the tide is exactly h, in
+// order. The times are precise to the second.
+func (c Curve) Crossings(h float64) []time.Time {
+	// Between critical points the curve is monotonic, so each piece crosses h
+	// at most once and bisection is guaranteed to find it.
+	xs := c.pieces()
+
+	var result []time.Time
+	record := func(x float64) {
+		t := c.Start.Add(time.Duration(math.Round(x)) * time.Second)
+		if n := len(result); n > 0 && result[n-1].Equal(t) {
+			return
+		}
+		result = append(result, t)
+	}
+
+	for i := 0; i+1 < len(xs); i++ {
+		x0, x1 := xs[i], xs[i+1]
+		f0, f1 := c.evalX(x0)-h, c.evalX(x1)-h
+		switch {
+		case f0 == 0:
+			record(x0)
+		case f0*f1 < 0:
+			record(bisect(func(x float64) float64 { return c.evalX(x) - h }, x0, x1))
+		}
+	}
+	if last := xs[len(xs)-1]; c.evalX(last) == h {
+		record(last)
+	}
+	return result
+}
+
+// Crossings returns the times within the spline where the tide is exactly h, in
+// order. The times are precise to the second.
+func (s Spline) Crossings(h float64) []time.Time {
+	var result []time.Time
+	for _, c := range s {
+		for _, t := range c.Crossings(h) {
+			// Curves share their endpoints, so don't report a joint twice.
+			if n := len(result); n > 0 && result[n-1].Equal(t) {
+				continue
+			}
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// Min returns the time and height of the lowest tide within [start, end]. If
+// the spline is not defined anywhere in that range, the height is NaN.
+func (s Spline) Min(start, end time.Time) (time.Time, float64) {
+	return s.extreme(start, end, func(a, b float64) bool { return a < b })
+}
+
+// Max returns the time and height of the highest tide within [start, end]. If
+// the spline is not defined anywhere in that range, the height is NaN.
+func (s Spline) Max(start, end time.Time) (time.Time, float64) {
+	return s.extreme(start, end, func(a, b float64) bool { return a > b })
+}
+
+// extreme finds the most extreme tide by better within [start, end]. The
+// extremes of a cubic are at its critical points or the ends of its domain, so
+// only those need to be checked.
+func (s Spline) extreme(start, end time.Time, better func(a, b float64) bool) (time.Time, float64) {
+	bestt, best := time.Time{}, math.NaN()
+	consider := func(t time.Time) {
+		if t.Before(start) || t.After(end) {
+			return
+		}
+		h := s.Eval(t)
+		if math.IsNaN(h) {
+			return
+		}
+		if math.IsNaN(best) || better(h, best) {
+			bestt, best = t, h
+		}
+	}
+
+	consider(start)
+	consider(end)
+	for _, c := range s {
+		if c.End.Before(start) || c.Start.After(end) {
+			continue
+		}
+		for _, x := range c.pieces() {
+			consider(c.Start.Add(time.Duration(math.Round(x)) * time.Second))
+		}
+	}
+	return bestt, best
+}
+
+// pieces returns the x coordinates that split the curve into monotonic pieces:
+// its start, any critical points in between, and its end.
+func (c Curve) pieces() []float64 {
+	width := xrel(c.Start, c.End)
+	xs := append([]float64{0}, c.shape.critical(width)...)
+	return append(xs, width)
+}
+
+// evalX evaluates the curve at x seconds after its start.
+func (c Curve) evalX(x float64) float64 {
+	return c.shape.eval(x)
+}
+
+// bisect finds a root of f between x0 and x1 to within half a second. f(x0)
+// and f(x1) must have opposite signs.
+func bisect(f func(float64) float64, x0, x1 float64) float64 {
+	f0 := f(x0)
+	for x1-x0 > 0.5 {
+		mid := x0 + (x1-x0)/2
+		fmid := f(mid)
+		if fmid == 0 {
+			return mid
+		}
+		if (fmid < 0) == (f0 < 0) {
+			x0, f0 = mid, fmid
+		} else {
+			x1 = mid
+		}
+	}
+	return x0 + (x1-x0)/2
+}
@@ -0,0 +1,105 @@
+package splines
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+var (
+	tstart = time.Date(2021, time.April, 3, 0, 0, 0, 0, time.UTC)
+
+	// testPreds falls from 5ft to -1ft and rises again over 12 hours.
+	testPreds = noaa.Predictions{
+		{Time: noaa.Time(tstart), Height: 5},
+		{Time: noaa.Time(tstart.Add(6 * time.Hour)), Height: -1},
+		{Time: noaa.Time(tstart.Add(12 * time.Hour)), Height: 5},
+	}
+)
+
+func TestCrossings(t *testing.T) {
+	spl := CurvesBetween(testPreds)
+
+	for _, tc := range []struct {
+		name string
+		h    float64
+		want int
+	}{
+		{"mid tide", 2, 2},
+		{"exactly the low", -1, 1},
+		{"exactly the high", 5, 2},
+		{"below everything", -2, 0},
+		{"above everything", 6, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := spl.Crossings(tc.h)
+			if len(got) != tc.want {
+				t.Fatalf("Crossings(%v) = %v, wanted %d crossings", tc.h, got, tc.want)
+			}
+			for _, at := range got {
+				if h := spl.Eval(at); math.Abs(h-tc.h) > 0.001 {
+					t.Errorf("tide at crossing %v is %f, wanted %f", at, h, tc.h)
+				}
+			}
+		})
+	}
+
+	// The curve is symmetric, so the crossings at mid tide are too.
+	got := spl.Crossings(2)
+	before, after := tstart.Add(6*time.Hour).Sub(got[0]), got[1].Sub(tstart.Add(6*time.Hour))
+	if d := before - after; d < -time.Second || d > time.Second {
+		t.Errorf("crossings %v are not symmetric about the low tide", got)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	spl := CurvesBetween(testPreds)
+
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		min, max   float64
+		mint, maxt time.Time
+	}{{
+		name:  "whole spline",
+		start: tstart,
+		end:   tstart.Add(12 * time.Hour),
+		min:   -1,
+		mint:  tstart.Add(6 * time.Hour),
+		max:   5,
+		maxt:  tstart,
+	}, {
+		name:  "falling part",
+		start: tstart.Add(1 * time.Hour),
+		end:   tstart.Add(5 * time.Hour),
+		min:   CurvesBetween(testPreds).Eval(tstart.Add(5 * time.Hour)),
+		mint:  tstart.Add(5 * time.Hour),
+		max:   CurvesBetween(testPreds).Eval(tstart.Add(1 * time.Hour)),
+		maxt:  tstart.Add(1 * time.Hour),
+	}, {
+		name:  "clipped to the domain",
+		start: tstart.Add(-5 * time.Hour),
+		end:   tstart.Add(7 * time.Hour),
+		min:   -1,
+		mint:  tstart.Add(6 * time.Hour),
+		max:   5,
+		maxt:  tstart,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			mint, min := spl.Min(tc.start, tc.end)
+			if !mint.Equal(tc.mint) || math.Abs(min-tc.min) > 0.001 {
+				t.Errorf("Min = %v at %v, wanted %v at %v", min, mint, tc.min, tc.mint)
+			}
+			maxt, max := spl.Max(tc.start, tc.end)
+			if !maxt.Equal(tc.maxt) || math.Abs(max-tc.max) > 0.001 {
+				t.Errorf("Max = %v at %v, wanted %v at %v", max, maxt, tc.max, tc.maxt)
+			}
+		})
+	}
+
+	if _, h := spl.Min(tstart.Add(24*time.Hour), tstart.Add(48*time.Hour)); !math.IsNaN(h) {
+		t.Errorf("Min outside the spline = %v, wanted NaN", h)
+	}
+}
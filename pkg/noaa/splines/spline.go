@@ -2,40 +2,27 @@
 package splines
 
 import (
-	"bytes"
-	"fmt"
 	"math"
 	"time"
 
 	"github.com/spencer-p/surfdash/pkg/noaa"
 )
 
-// Curve represents a curve that links a tide event to another smoothly. Its
-// derivitative at Start and End are zero and it is undefined outside Start and
-// End.
+// Curve represents a curve that links a tide event to another smoothly. It is
+// undefined outside Start and End. The Model that created the curve determines
+// its shape in between.
 type Curve struct {
 	Start, End time.Time
-	a, b, c, d float64
+	shape      shape
 }
 
 // A Spline is a slice of curves linked together to form a full picture.
 type Spline []Curve
 
-// CurvesBetween identifies curves to link NOAA tide predictions.
+// CurvesBetween identifies curves to link NOAA tide predictions. The curves
+// are cubics with zero derivative at each prediction; see Cubic.
 func CurvesBetween(preds noaa.Predictions) Spline {
-	if len(preds) < 2 {
-		return nil
-	}
-
-	curves := make([]Curve, len(preds)-1)
-	for i := 0; i < len(preds)-1; i++ {
-		curves[i] = curveBetween(
-			time.Time(preds[i].Time),
-			float64(preds[i].Height),
-			time.Time(preds[i+1].Time),
-			float64(preds[i+1].Height))
-	}
-	return curves
+	return Cubic.Interpolate(preds)
 }
 
 // Discrete finds n tide predictions within the tide predictions described by a
@@ -67,37 +54,25 @@ func curveBetween(time1 time.Time, h1 float64, time2 time.Time, h2 float64) Curv
 	curve := Curve{
 		Start: time1,
 		End:   time2,
-		a:     a,
-		b:     b,
-		c:     c,
-		d:     d,
+		shape: cubic{a, b, c, d},
 	}
 	return curve
 }
 
 func (s Spline) Eval(t time.Time) float64 {
-	n := len(s)
-	left, right := 0, n
-	for right > left {
-		mid := left + (right-left)/2
-		if t.Before(s[mid].Start) {
-			right = mid
-		} else if t.After(s[mid].End) {
-			left = mid
-		} else {
-			return s[mid].Eval(t)
-		}
+	i, ok := s.find(t)
+	if !ok {
+		// Function not defined.
+		return math.NaN()
 	}
-	// Function not defined.
-	return math.NaN()
+	return s[i].Eval(t)
 }
 
 func (c Curve) Eval(t time.Time) float64 {
 	if t.Before(c.Start) || t.After(c.End) {
 		return math.NaN()
 	}
-	x := xrel(c.Start, t)
-	return c.a*x*x*x + c.b*x*x + c.c*x + c.d
+	return c.shape.eval(xrel(c.Start, t))
 }
 
 // xrel computes an x coordinate for t that is relative to origin.
@@ -106,11 +81,3 @@ func (c Curve) Eval(t time.Time) float64 {
 func xrel(origin time.Time, t time.Time) float64 {
 	return float64(t.Unix() - origin.Unix())
 }
-
-func (c Curve) MarshalJSON() ([]byte, error) {
-	var buf bytes.Buffer
-	_, err := fmt.Fprintf(&buf, `{"start":%d,"end":%d,"a":%g,"b":%g,"c":%g,"d":%g}`,
-		c.Start.Unix(), c.End.Unix(),
-		c.a, c.b, c.c, c.d)
-	return buf.Bytes(), err
-}
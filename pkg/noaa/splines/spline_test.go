@@ -34,7 +34,7 @@ func ExampleDiscrete() {
 	// 1
 }
 
-func ExampleSolve() {
+func ExampleCurvesBetween() {
 	tstart := time.Time{}
 	tend := tstart.Add(10 * time.Second)
 	preds := noaa.Predictions{{
@@ -45,10 +45,11 @@ func ExampleSolve() {
 		Height: 10,
 	}}
 	curve := CurvesBetween(preds)[0]
-	fmt.Printf("A = %.2f\n", curve.a)
-	fmt.Printf("B = %.2f\n", curve.b)
-	fmt.Printf("C = %.2f\n", curve.c)
-	fmt.Printf("D = %.2f\n", curve.d)
+	cub := curve.shape.(cubic)
+	fmt.Printf("A = %.2f\n", cub.a)
+	fmt.Printf("B = %.2f\n", cub.b)
+	fmt.Printf("C = %.2f\n", cub.c)
+	fmt.Printf("D = %.2f\n", cub.d)
 	// Output:
 	// A = -0.02
 	// B = 0.30
@@ -0,0 +1,10 @@
+// Evaluates the golden spline with static/tide.js and prints the heights at
+// each sample time as JSON, so TestGoldenSplineJS can compare them with Go.
+const fs = require("fs");
+const path = require("path");
+const tide = require(path.join(__dirname, "../../../../static/tide.js"));
+
+const golden = JSON.parse(fs.readFileSync(path.join(__dirname, "golden_spline.json")));
+const spline = tide.decodeSpline(JSON.stringify(golden.spline));
+const heights = golden.samples.map(([t]) => tide.evalSpline(spline, 0, t));
+console.log(JSON.stringify(heights));
@@ -38,8 +38,25 @@ type PredictionQuery struct {
 	Start    time.Time
 	Duration time.Duration
 	Station  Station
+	// Interval is the spacing of the predictions. If unset, only high and
+	// low tides are predicted.
+	Interval Interval
+	// Location is the time zone of the predicted times. If unset, they are
+	// in UTC.
+	Location *time.Location
 }
 
+// Interval is the spacing of tide predictions.
+type Interval string
+
+const (
+	// HiLo predicts only high and low tides.
+	HiLo Interval = "hilo"
+	// SixMinute predicts the tide every six minutes. These predictions do
+	// not have a Type.
+	SixMinute Interval = "6"
+)
+
 type Station int
 
 const (
@@ -53,7 +70,8 @@ func (t *Time) UnmarshalJSON(buf []byte) error {
 	if err := json.Unmarshal(buf, &s); err != nil {
 		return fmt.Errorf("prediction time %q not string: %w", buf, err)
 	}
-	parsed, err := time.ParseInLocation(predTimeFormat, s, time.Local)
+	// Queries ask NOAA for times in GMT.
+	parsed, err := time.ParseInLocation(predTimeFormat, s, time.UTC)
 	if err != nil {
 		return fmt.Errorf("prediction time %q not in fmt %q: %w", s, predTimeFormat, err)
 	}
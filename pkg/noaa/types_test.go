@@ -17,14 +17,14 @@ func TestParsePrediction(t *testing.T) {
 	}{{
 		input: `{"t":"2020-10-20 02:17", "v":"4.080", "type":"H"}`,
 		want: Prediction{
-			Time:   Time(time.Date(2020, time.October, 20, 2, 17, 0, 0, time.Local)),
+			Time:   Time(time.Date(2020, time.October, 20, 2, 17, 0, 0, time.UTC)),
 			Height: 4.08,
 			Type:   HighTide,
 		},
 	}, {
 		input: `{"t":"2019-09-21 06:56", "v":"2.559", "type":"L"}`,
 		want: Prediction{
-			Time:   Time(time.Date(2019, time.September, 21, 6, 56, 0, 0, time.Local)),
+			Time:   Time(time.Date(2019, time.September, 21, 6, 56, 0, 0, time.UTC)),
 			Height: 2.559,
 			Type:   LowTide,
 		},
@@ -0,0 +1,79 @@
+package sunset
+
+import (
+	"sort"
+	"time"
+)
+
+// Search returns the index of the first event that occurs at or after t. If
+// every event occurs before t, it returns len(evs).
+func (evs SunEvents) Search(t time.Time) int {
+	return sort.Search(len(evs), func(i int) bool {
+		return !evs[i].Time.Before(t)
+	})
+}
+
+// IndexAtOrBefore returns the index of the last event that occurs at or before
+// t, or -1 if there is none.
+func (evs SunEvents) IndexAtOrBefore(t time.Time) int {
+	i := evs.Search(t)
+	if i < len(evs) && evs[i].Time.Equal(t) {
+		return i
+	}
+	return i - 1
+}
+
+// Next returns the first event of the given kind that occurs at or after t.
+func (evs SunEvents) Next(t time.Time, kind Event) (SunEvent, bool) {
+	for i := evs.Search(t); i < len(evs); i++ {
+		if evs[i].Event == kind {
+			return evs[i], true
+		}
+	}
+	return SunEvent{}, false
+}
+
+// Prev returns the last event of the given kind that occurs strictly before t.
+func (evs SunEvents) Prev(t time.Time, kind Event) (SunEvent, bool) {
+	for i := evs.Search(t) - 1; i >= 0; i-- {
+		if evs[i].Event == kind {
+			return evs[i], true
+		}
+	}
+	return SunEvent{}, false
+}
+
+// Between returns the events that occur in the half open range [start, end).
+// The result shares memory with evs.
+func (evs SunEvents) Between(start, end time.Time) SunEvents {
+	i, j := evs.Search(start), evs.Search(end)
+	if j < i {
+		return evs[i:i]
+	}
+	return evs[i:j]
+}
+
+// Daylight returns the sunrise and following sunset on the calendar date of
+// date. If the events do not cover that date, ok is false.
+func (evs SunEvents) Daylight(date time.Time) (rise, set time.Time, ok bool) {
+	y, m, d := date.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+
+	sunrise, ok := evs.Next(midnight, Sunrise)
+	if !ok || !sameDate(sunrise.Time, date) {
+		return time.Time{}, time.Time{}, false
+	}
+	sunset, ok := evs.Next(sunrise.Time, Sunset)
+	if !ok || !sameDate(sunset.Time, date) {
+		return time.Time{}, time.Time{}, false
+	}
+	return sunrise.Time, sunset.Time, true
+}
+
+// sameDate reports whether t falls on the calendar date of date, in date's
+// location.
+func sameDate(t, date time.Time) bool {
+	y1, m1, d1 := t.In(date.Location()).Date()
+	y2, m2, d2 := date.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
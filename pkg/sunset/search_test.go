@@ -0,0 +1,202 @@
+package sunset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2020, time.October, day, hour, minute, 0, 0, SantaCruz.Location)
+}
+
+var testEvents = SunEvents{
+	{at(30, 7, 31), Sunrise},
+	{at(30, 18, 13), Sunset},
+	{at(31, 7, 33), Sunrise},
+	{at(31, 18, 12), Sunset},
+}
+
+func TestSearch(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		t             time.Time
+		search, atOrB int
+	}{{
+		name:   "before all",
+		t:      at(29, 12, 0),
+		search: 0,
+		atOrB:  -1,
+	}, {
+		name:   "exactly first",
+		t:      at(30, 7, 31),
+		search: 0,
+		atOrB:  0,
+	}, {
+		name:   "between",
+		t:      at(30, 12, 0),
+		search: 1,
+		atOrB:  0,
+	}, {
+		name:   "exactly last",
+		t:      at(31, 18, 12),
+		search: 3,
+		atOrB:  3,
+	}, {
+		name:   "after all",
+		t:      at(31, 23, 0),
+		search: 4,
+		atOrB:  3,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := testEvents.Search(tc.t); got != tc.search {
+				t.Errorf("Search(%v) = %d, wanted %d", tc.t, got, tc.search)
+			}
+			if got := testEvents.IndexAtOrBefore(tc.t); got != tc.atOrB {
+				t.Errorf("IndexAtOrBefore(%v) = %d, wanted %d", tc.t, got, tc.atOrB)
+			}
+		})
+	}
+
+	if got := (SunEvents{}).Search(at(30, 0, 0)); got != 0 {
+		t.Errorf("Search on empty events = %d, wanted 0", got)
+	}
+	if got := (SunEvents{}).IndexAtOrBefore(at(30, 0, 0)); got != -1 {
+		t.Errorf("IndexAtOrBefore on empty events = %d, wanted -1", got)
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		t      time.Time
+		kind   Event
+		next   time.Time
+		nextOk bool
+		prev   time.Time
+		prevOk bool
+	}{{
+		name:   "sunrise at a sunrise",
+		t:      at(31, 7, 33),
+		kind:   Sunrise,
+		next:   at(31, 7, 33),
+		nextOk: true,
+		prev:   at(30, 7, 31),
+		prevOk: true,
+	}, {
+		name:   "sunset before any data",
+		t:      at(29, 0, 0),
+		kind:   Sunset,
+		next:   at(30, 18, 13),
+		nextOk: true,
+		prevOk: false,
+	}, {
+		name:   "sunrise after all data",
+		t:      at(31, 20, 0),
+		kind:   Sunrise,
+		nextOk: false,
+		prev:   at(31, 7, 33),
+		prevOk: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			next, ok := testEvents.Next(tc.t, tc.kind)
+			if ok != tc.nextOk || (ok && !next.Time.Equal(tc.next)) {
+				t.Errorf("Next(%v) = %v, %t, wanted %v, %t", tc.t, next.Time, ok, tc.next, tc.nextOk)
+			}
+			prev, ok := testEvents.Prev(tc.t, tc.kind)
+			if ok != tc.prevOk || (ok && !prev.Time.Equal(tc.prev)) {
+				t.Errorf("Prev(%v) = %v, %t, wanted %v, %t", tc.t, prev.Time, ok, tc.prev, tc.prevOk)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		want       SunEvents
+	}{{
+		name:  "half open range",
+		start: at(30, 7, 31),
+		end:   at(31, 7, 33),
+		want:  testEvents[0:2],
+	}, {
+		name:  "empty range",
+		start: at(30, 12, 0),
+		end:   at(30, 12, 0),
+		want:  SunEvents{},
+	}, {
+		name:  "inverted range",
+		start: at(31, 12, 0),
+		end:   at(30, 12, 0),
+		want:  SunEvents{},
+	}, {
+		name:  "everything",
+		start: at(1, 0, 0),
+		end:   at(31, 23, 59),
+		want:  testEvents,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testEvents.Between(tc.start, tc.end)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Between(%v, %v) (-want,+got):\n%s", tc.start, tc.end, diff)
+			}
+		})
+	}
+}
+
+func TestDaylight(t *testing.T) {
+	rise, set, ok := testEvents.Daylight(at(31, 23, 0))
+	if !ok || !rise.Equal(at(31, 7, 33)) || !set.Equal(at(31, 18, 12)) {
+		t.Errorf("Daylight = %v, %v, %t; wanted the events on 10/31", rise, set, ok)
+	}
+
+	// The day before has no sunrise, and the day after has no data at all.
+	for _, date := range []time.Time{at(29, 12, 0), time.Date(2020, time.November, 1, 12, 0, 0, 0, SantaCruz.Location)} {
+		if _, _, ok := testEvents.Daylight(date); ok {
+			t.Errorf("Daylight(%v) unexpectedly ok", date)
+		}
+	}
+
+	// A sunrise without the matching sunset cannot make a day.
+	if _, _, ok := testEvents[:3].Daylight(at(31, 12, 0)); ok {
+		t.Errorf("Daylight without sunset unexpectedly ok")
+	}
+}
+
+func TestSunUpBoundaries(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		evs  SunEvents
+		t    time.Time
+		want bool
+	}{{
+		name: "exactly sunrise",
+		evs:  testEvents,
+		t:    at(30, 7, 31),
+		want: true,
+	}, {
+		name: "exactly sunset",
+		evs:  testEvents,
+		t:    at(30, 18, 13),
+		want: false,
+	}, {
+		name: "sunrise without sunset",
+		evs:  testEvents[:1],
+		t:    at(30, 12, 0),
+		want: false,
+	}, {
+		name: "empty",
+		evs:  SunEvents{},
+		t:    at(30, 12, 0),
+		want: false,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.evs.SunUp(tc.t); got != tc.want {
+				t.Errorf("SunUp(%v)=%v, wanted %v", tc.t, got, tc.want)
+			}
+		})
+	}
+}
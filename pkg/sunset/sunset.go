@@ -41,32 +41,14 @@ func getDays(t time.Duration) int {
 	return int(math.Ceil(t.Hours() / 24))
 }
 
-// SunUp returns true if the sun is up at the given time.
+// SunUp returns true if the sun is up at the given time, that is, t is at or
+// after a sunrise and before the following sunset.
 // If the SunEvents provided cannot say, it returns false.
 func (evs SunEvents) SunUp(t time.Time) bool {
-	n := len(evs)
-	left, right := 0, n
-	// There must always be two elements (after rise and post set) to consider.
-	for right-left >= 2 {
-		// evs[mid] and evs[mid-1] are defined because there are at least two
-		// elements.
-		mid := left + (right-left)/2
-		if t.After(evs[mid-1].Time) && t.Before(evs[mid].Time) {
-			// Check if t falls between mid-1 and mid.
-			// If those events are rise and set, sun is up.
-			if evs[mid-1].Event == Sunrise &&
-				evs[mid].Event == Sunset {
-				return true
-			} else {
-				return false
-			}
-		} else if t.Before(evs[mid-1].Time) {
-			right = mid
-		} else {
-			left = mid
-		}
-	}
-	return false
+	i := evs.IndexAtOrBefore(t)
+	return i >= 0 && i+1 < len(evs) &&
+		evs[i].Event == Sunrise &&
+		evs[i+1].Event == Sunset
 }
 
 // Dawn returns true if t is just before or at dawn.
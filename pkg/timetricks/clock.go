@@ -0,0 +1,95 @@
+package timetricks
+
+import (
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// System is the clock of the machine.
+var System Clock = ClockFunc(time.Now)
+
+// Fixed returns a Clock that is always at t.
+func Fixed(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// Calendar reckons days in a time zone by a clock. Times given to its methods
+// may be in any location; they are compared by their dates in the calendar's
+// location.
+type Calendar struct {
+	Clock    Clock
+	Location *time.Location
+}
+
+// Local is the calendar of the system clock in the local time zone.
+var Local = Calendar{Clock: System, Location: time.Local}
+
+// In returns a calendar for loc using the system clock.
+func In(loc *time.Location) Calendar {
+	return Calendar{Clock: System, Location: loc}
+}
+
+// Now returns the current time in the calendar's location.
+func (c Calendar) Now() time.Time {
+	return c.Clock.Now().In(c.Location)
+}
+
+// Midnight returns the start of the day of t.
+func (c Calendar) Midnight(t time.Time) time.Time {
+	return TrimClock(t.In(c.Location))
+}
+
+// SameDay returns true if t and t2 fall on the same date.
+func (c Calendar) SameDay(t, t2 time.Time) bool {
+	return SameDay(t.In(c.Location), t2.In(c.Location))
+}
+
+// Today returns true if t is today.
+func (c Calendar) Today(t time.Time) bool {
+	return c.SameDay(t, c.Now())
+}
+
+// Tomorrow returns true if t is tomorrow.
+func (c Calendar) Tomorrow(t time.Time) bool {
+	return c.SameDay(t, c.Midnight(c.Now()).AddDate(0, 0, 1))
+}
+
+// WithinWeek returns true if t occurs in the upcoming week from today.
+func (c Calendar) WithinWeek(t time.Time) bool {
+	// Check that t occurs after the start of today (minus a minute in case t
+	// falls at midnight) and before the first minute of the coming week.
+	today := c.Midnight(c.Now())
+	firstMinuteOfNextWeek := today.AddDate(0, 0, 7).Add(time.Minute)
+	return t.After(today.Add(-1*time.Minute)) && t.Before(firstMinuteOfNextWeek)
+}
+
+// Day returns a pretty string for the day, i.e. "Today" or "Monday" or "5/25".
+func (c Calendar) Day(t time.Time) string {
+	return c.DayWith(t, English)
+}
+
+// DayWith is like Day, using the given names.
+func (c Calendar) DayWith(t time.Time, names DayNames) string {
+	t = t.In(c.Location)
+	if c.Today(t) {
+		return names.Today
+	} else if c.Tomorrow(t) {
+		return names.Tomorrow
+	} else if c.WithinWeek(t) {
+		return names.Weekdays[t.Weekday()]
+	} else {
+		return t.Format(names.DateFmt)
+	}
+}
@@ -0,0 +1,140 @@
+package timetricks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func pacific(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	return loc
+}
+
+func TestTrimClock(t *testing.T) {
+	la := pacific(t)
+	for _, tc := range []struct {
+		name string
+		in   time.Time
+		want time.Time
+	}{{
+		name: "ordinary day",
+		in:   time.Date(2021, time.June, 1, 13, 30, 0, 0, la),
+		want: time.Date(2021, time.June, 1, 0, 0, 0, 0, la),
+	}, {
+		name: "spring forward",
+		in:   time.Date(2021, time.March, 14, 12, 0, 0, 0, la),
+		want: time.Date(2021, time.March, 14, 0, 0, 0, 0, la),
+	}, {
+		name: "fall back",
+		in:   time.Date(2021, time.November, 7, 12, 0, 0, 0, la),
+		want: time.Date(2021, time.November, 7, 0, 0, 0, 0, la),
+	}, {
+		name: "second 1am of fall back",
+		in:   time.Date(2021, time.November, 7, 1, 30, 0, 0, la).Add(time.Hour),
+		want: time.Date(2021, time.November, 7, 0, 0, 0, 0, la),
+	}, {
+		name: "late on spring forward",
+		in:   time.Date(2021, time.March, 14, 23, 59, 59, 999, la),
+		want: time.Date(2021, time.March, 14, 0, 0, 0, 0, la),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TrimClock(tc.in)
+			if !got.Equal(tc.want) {
+				t.Errorf("TrimClock(%v) = %v, wanted %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	la := pacific(t)
+	for _, tc := range []struct {
+		name         string
+		in           time.Time
+		hour, minute time.Duration
+		want         time.Time
+	}{{
+		name: "ordinary day",
+		in:   time.Date(2021, time.June, 1, 8, 0, 0, 0, la),
+		hour: 16, minute: 27,
+		want: time.Date(2021, time.June, 1, 16, 27, 0, 0, la),
+	}, {
+		name: "spring forward",
+		in:   time.Date(2021, time.March, 14, 8, 0, 0, 0, la),
+		hour: 16, minute: 27,
+		want: time.Date(2021, time.March, 14, 16, 27, 0, 0, la),
+	}, {
+		name: "fall back",
+		in:   time.Date(2021, time.November, 7, 8, 0, 0, 0, la),
+		hour: 16, minute: 27,
+		want: time.Date(2021, time.November, 7, 16, 27, 0, 0, la),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SetClock(tc.in, tc.hour, tc.minute)
+			if !got.Equal(tc.want) {
+				t.Errorf("SetClock(%v, %d, %d) = %v, wanted %v", tc.in, tc.hour, tc.minute, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	la := pacific(t)
+	for _, tc := range []struct {
+		name  string
+		now   time.Time
+		times []time.Time
+		want  []string
+	}{{
+		name: "night before spring forward",
+		now:  time.Date(2021, time.March, 13, 23, 30, 0, 0, la),
+		times: []time.Time{
+			time.Date(2021, time.March, 13, 0, 0, 0, 0, la),
+			time.Date(2021, time.March, 14, 0, 0, 0, 0, la),
+			time.Date(2021, time.March, 14, 23, 59, 0, 0, la),
+			time.Date(2021, time.March, 15, 0, 0, 0, 0, la),
+			time.Date(2021, time.March, 19, 23, 0, 0, 0, la),
+			time.Date(2021, time.March, 20, 0, 0, 0, 0, la),
+			time.Date(2021, time.March, 20, 0, 1, 0, 0, la),
+		},
+		want: []string{"Today", "Tomorrow", "Tomorrow", "Monday", "Friday", "Saturday", "03/20"},
+	}, {
+		name: "night before fall back",
+		now:  time.Date(2021, time.November, 6, 23, 30, 0, 0, la),
+		times: []time.Time{
+			time.Date(2021, time.November, 6, 23, 59, 0, 0, la),
+			time.Date(2021, time.November, 7, 0, 0, 0, 0, la),
+			time.Date(2021, time.November, 7, 23, 30, 0, 0, la),
+			time.Date(2021, time.November, 8, 0, 0, 0, 0, la),
+			time.Date(2021, time.November, 13, 0, 0, 0, 0, la),
+			time.Date(2021, time.November, 13, 0, 1, 0, 0, la),
+		},
+		want: []string{"Today", "Tomorrow", "Tomorrow", "Monday", "Saturday", "11/13"},
+	}, {
+		name: "times in another zone",
+		now:  time.Date(2021, time.June, 1, 20, 0, 0, 0, la),
+		times: []time.Time{
+			// 03:00 UTC on June 2nd is still June 1st in California.
+			time.Date(2021, time.June, 2, 3, 0, 0, 0, time.UTC),
+			time.Date(2021, time.June, 2, 8, 0, 0, 0, time.UTC),
+		},
+		want: []string{"Today", "Tomorrow"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cal := Calendar{Clock: Fixed(tc.now), Location: la}
+			var got []string
+			for _, when := range tc.times {
+				got = append(got, cal.Day(when))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("days (-want,+got): %s", diff)
+			}
+		})
+	}
+}
@@ -5,9 +5,8 @@ import (
 )
 
 const (
-	dayFmt         = "01/02"
-	dayFormat      = "20060102"
-	weekPlusMinute = 7*24*time.Hour + time.Minute
+	dayFmt    = "01/02"
+	dayFormat = "20060102"
 )
 
 // SameDay returns true if t and t2 represent the same calendar date.
@@ -15,44 +14,35 @@ func SameDay(t time.Time, t2 time.Time) bool {
 	return t.Format(dayFormat) == t2.Format(dayFormat)
 }
 
-// Today returns true if t is today.
+// Today returns true if t is today in the local time zone.
 func Today(t time.Time) bool {
-	return SameDay(t, time.Now())
+	return Local.Today(t)
 }
 
-// Tomorrow returns true if t is tomorrow.
+// Tomorrow returns true if t is tomorrow in the local time zone.
 func Tomorrow(t time.Time) bool {
-	return Today(t.Add(-24 * time.Hour))
+	return Local.Tomorrow(t)
 }
 
 // TrimClock removes the wall clock time from t. The resulting time occurs on
-// the same day at 00:00:00.00.
+// the same day at 00:00:00.00 in the location of t, even on days that are
+// not 24 hours long.
 func TrimClock(t time.Time) time.Time {
-	ns := t.Nanosecond()
-	h, m, s := t.Clock()
-	return t.Add(-1 *
-		(time.Duration(h)*time.Hour +
-			time.Duration(m)*time.Minute +
-			time.Duration(s)*time.Second +
-			time.Duration(ns)*time.Nanosecond))
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
 }
 
-// WithinWeek returns true if t occurs in the upcoming week from today.
+// WithinWeek returns true if t occurs in the upcoming week from today in the
+// local time zone.
 func WithinWeek(t time.Time) bool {
-	// Trim current time so they have no wall clock component, just
-	// calendar date, and use it to compute the first minute of the coming week.
-	// Then check if our time t occurs before then, as well as after the start
-	// of today (minus a minute in case t falls at midnight).
-	now := TrimClock(time.Now())
-	firstMinuteOfNextWeek := now.Add(weekPlusMinute)
-	return t.After(now.Add(-1*time.Minute)) && t.Before(firstMinuteOfNextWeek)
-
+	return Local.WithinWeek(t)
 }
 
 // SetClock sets the wall clock time of t to match the given hour, minute, and
 // no seconds.
 func SetClock(t time.Time, hour, minute time.Duration) time.Time {
-	return TrimClock(t).Add(hour*time.Hour + minute*time.Minute)
+	y, m, d := t.Date()
+	return time.Date(y, m, d, int(hour), int(minute), 0, 0, t.Location())
 }
 
 // UniqueDay returns a string representation of t that is unique by the day.
@@ -62,15 +52,29 @@ func UniqueDay(t time.Time) string {
 	return t.Format(dayFormat)
 }
 
+// DayNames are the words Day uses for days.
+type DayNames struct {
+	Today, Tomorrow string
+	// Weekdays are indexed by time.Weekday.
+	Weekdays [7]string
+	// DateFmt formats days more than a week away.
+	DateFmt string
+}
+
+// English are the day names of Day.
+var English = DayNames{
+	Today:    "Today",
+	Tomorrow: "Tomorrow",
+	Weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	DateFmt:  dayFmt,
+}
+
 // Day returns a pretty string for the day, i.e. "Today" or "Monday" or "5/25".
 func Day(t time.Time) string {
-	if Today(t) {
-		return "Today"
-	} else if Tomorrow(t) {
-		return "Tomorrow"
-	} else if WithinWeek(t) {
-		return t.Weekday().String()
-	} else {
-		return t.Format(dayFmt)
-	}
+	return Local.Day(t)
+}
+
+// DayWith is like Day, using the given names.
+func DayWith(t time.Time, names DayNames) string {
+	return Local.DayWith(t, names)
 }
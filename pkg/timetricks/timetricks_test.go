@@ -28,3 +28,19 @@ func ExampleTrimClock() {
 	// Output:
 	// 2020-03-14 00:00:00 +0000 UTC
 }
+
+func ExampleDayWith() {
+	spanish := DayNames{
+		Today:    "Hoy",
+		Tomorrow: "Mañana",
+		Weekdays: [7]string{"Domingo", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"},
+		DateFmt:  "02/01",
+	}
+	fmt.Println(DayWith(time.Now(), spanish))
+	fmt.Println(DayWith(time.Now().Add(24*time.Hour), spanish))
+	fmt.Println(DayWith(time.Date(2020, 03, 14, 19, 45, 6, 500, time.UTC), spanish))
+	// Output:
+	// Hoy
+	// Mañana
+	// 14/03
+}
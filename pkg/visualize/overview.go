@@ -0,0 +1,301 @@
+package visualize
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/interval"
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+	"github.com/spencer-p/surfdash/pkg/timetricks"
+)
+
+const (
+	// DefaultStripWidth and DefaultStripHeight are the dimensions of a Strip
+	// unless set otherwise.
+	DefaultStripWidth  = 1400
+	DefaultStripHeight = 200
+
+	// stripStep is the distance in pixels between samples of the tide.
+	stripStep = 2
+
+	// heatmapCell is the size of each hour of a Heatmap, and heatmapLabel is
+	// the width of the day labels to its left.
+	heatmapCell  = 20
+	heatmapLabel = 100
+)
+
+// dayLabel names the weekday of day briefly, followed by its date.
+func dayLabel(l i18n.Locale, day time.Time) string {
+	return l.T(day.Format("Mon")) + " " + l.Date(day)
+}
+
+// Strip is a tide chart of several days in a row.
+type Strip struct {
+	start     time.Time
+	days      int
+	tidePreds noaa.Predictions
+	sunEvents sunset.SunEvents
+	goodTimes []meta.GoodTime
+	// model interpolates tidePreds into the tide between predictions.
+	model splines.Model
+	// locale formats the labels and descriptions.
+	locale i18n.Locale
+
+	width, height int
+}
+
+// NewStrip creates a Strip of the given number of days from the day of start.
+func NewStrip(tidePreds noaa.Predictions, sunEvents sunset.SunEvents, start time.Time, days int) *Strip {
+	return &Strip{
+		start:     timetricks.TrimClock(start),
+		days:      days,
+		tidePreds: tidePreds,
+		sunEvents: sunEvents,
+		model:     splines.Cubic,
+		locale:    i18n.English,
+		width:     DefaultStripWidth,
+		height:    DefaultStripHeight,
+	}
+}
+
+// SetSize sets the dimensions of the image.
+func (img *Strip) SetSize(width, height int) {
+	img.width, img.height = width, height
+}
+
+// SetModel sets the model that interpolates the tide and the predictions it
+// expects. By default, the high and low tides are linked by splines.Cubic.
+func (img *Strip) SetModel(model splines.Model, preds noaa.Predictions) {
+	img.model, img.tidePreds = model, preds
+}
+
+// SetLocale sets the locale of the labels and descriptions of the chart.
+func (img *Strip) SetLocale(l i18n.Locale) {
+	img.locale = l
+}
+
+// SetGoodTimes sets the good times to shade on the chart.
+func (img *Strip) SetGoodTimes(goodTimes []meta.GoodTime) {
+	img.goodTimes = goodTimes
+}
+
+func (img *Strip) end() time.Time {
+	return img.start.AddDate(0, 0, img.days)
+}
+
+func (img *Strip) timeToX(t time.Time) int {
+	return int(float64(img.width) * float64(t.Sub(img.start)) / float64(img.end().Sub(img.start)))
+}
+
+func (img *Strip) xToTime(x int) time.Time {
+	return img.start.Add(time.Duration(float64(img.end().Sub(img.start)) * float64(x) / float64(img.width)))
+}
+
+// Encode writes the chart as an SVG.
+func (img *Strip) Encode(w io.Writer) (int, error) {
+	if img.days <= 0 {
+		return 0, fmt.Errorf("cannot draw %d days", img.days)
+	}
+	var b bytes.Buffer
+	width, height := img.width, img.height
+	spl := img.model.Interpolate(img.tidePreds)
+	low, high := img.fitRange(spl)
+	toY := func(h float64) int {
+		return height - int((h-low)*float64(height)/(high-low))
+	}
+
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg" class="strip" role="img" aria-label="%s">`,
+		width, height, html.EscapeString(img.locale.T("Tide chart for %d days from %s", img.days, img.locale.LongDate(img.start))))
+	fmt.Fprintf(&b, `<rect class="daytime" fill="lightyellow" x="0" y="0" width="%d" height="%d"/>`, width, height)
+
+	// Draw the tide as one path, sampled from the spline.
+	var path bytes.Buffer
+	first := true
+	lastX := 0
+	for x := 0; x <= width; x += stripStep {
+		h := spl.Eval(img.xToTime(x))
+		if math.IsNaN(h) {
+			continue
+		}
+		if first {
+			fmt.Fprintf(&path, "M %d,%d ", x, height)
+			first = false
+		}
+		fmt.Fprintf(&path, "L %d,%d ", x, clamp(toY(h), 0, height))
+		lastX = x
+	}
+	if !first {
+		fmt.Fprintf(&b, `<path class="tide" fill="skyblue" d="%sL %d,%d z"/>`, path.String(), lastX, height)
+	}
+
+	for _, gt := range img.goodTimes {
+		x1 := clamp(img.timeToX(gt.Time), 0, width)
+		x2 := clamp(img.timeToX(gt.Time.Add(gt.Duration)), 0, width)
+		if x2 > x1 {
+			fmt.Fprintf(&b, `<rect class="good_window" fill="#2a9d8f" fill-opacity="35%%" x="%d" y="0" width="%d" height="%d"/>`,
+				x1, x2-x1, height)
+		}
+	}
+
+	for _, night := range img.nights() {
+		x1, x2 := img.timeToX(night.Start), img.timeToX(night.End)
+		fmt.Fprintf(&b, `<rect class="night" fill="blue" fill-opacity="25%%" x="%d" y="0" width="%d" height="%d"/>`,
+			x1, x2-x1, height)
+	}
+
+	// Separate and label the days.
+	fontSize := height / 12
+	fmt.Fprintf(&b, `<g class="labels" fill="#2b3238" font-size="%d" font-family="monospace">`, fontSize)
+	for d := 0; d < img.days; d++ {
+		day := img.start.AddDate(0, 0, d)
+		x := img.timeToX(day)
+		if d > 0 {
+			fmt.Fprintf(&b, `<line class="day_separator" stroke="#2b3238" x1="%d" y1="0" x2="%d" y2="%d"/>`, x, x, height)
+		}
+		fmt.Fprintf(&b, `<text class="day_label" x="%d" y="%d">%s</text>`, x+fontSize/2, fontSize+fontSize/2, html.EscapeString(dayLabel(img.locale, day)))
+	}
+	io.WriteString(&b, `</g>`)
+	io.WriteString(&b, `</svg>`)
+
+	return w.Write(b.Bytes())
+}
+
+// fitRange returns whole feet above and below the tide during the chart.
+func (img *Strip) fitRange(spl splines.Spline) (low, high float64) {
+	_, low = spl.Min(img.start, img.end())
+	_, high = spl.Max(img.start, img.end())
+	if math.IsNaN(low) || math.IsNaN(high) {
+		return -2, 8
+	}
+	return math.Floor(low) - rangePadding, math.Ceil(high) + rangePadding
+}
+
+// nights returns the times between sunset and sunrise during the chart.
+func (img *Strip) nights() interval.Set {
+	var days []interval.Interval
+	for i := 0; i+1 < len(img.sunEvents); i++ {
+		if img.sunEvents[i].Event == sunset.Sunrise && img.sunEvents[i+1].Event == sunset.Sunset {
+			days = append(days, interval.Interval{Start: img.sunEvents[i].Time, End: img.sunEvents[i+1].Time})
+		}
+	}
+	chart := interval.New(interval.Interval{Start: img.start, End: img.end()})
+	return chart.Difference(interval.New(days...))
+}
+
+// Heatmap is a grid with a row for each day and a column for each hour,
+// shaded by how much of the hour is a good time.
+type Heatmap struct {
+	start     time.Time
+	days      int
+	goodTimes []meta.GoodTime
+	// locale formats the labels and descriptions.
+	locale i18n.Locale
+}
+
+// NewHeatmap creates a Heatmap of the given number of days from the day of
+// start.
+func NewHeatmap(goodTimes []meta.GoodTime, start time.Time, days int) *Heatmap {
+	return &Heatmap{
+		start:     timetricks.TrimClock(start),
+		days:      days,
+		goodTimes: goodTimes,
+		locale:    i18n.English,
+	}
+}
+
+// SetLocale sets the locale of the labels and descriptions of the heatmap.
+func (img *Heatmap) SetLocale(l i18n.Locale) {
+	img.locale = l
+}
+
+// Coverage returns the fraction of each hour of each day that is a good time.
+func (img *Heatmap) Coverage() [][24]float64 {
+	var windows []interval.Interval
+	for _, gt := range img.goodTimes {
+		windows = append(windows, interval.Interval{Start: gt.Time, End: gt.Time.Add(gt.Duration)})
+	}
+	good := interval.New(windows...)
+
+	result := make([][24]float64, img.days)
+	for d := range result {
+		starts := hourStarts(img.start.AddDate(0, 0, d))
+		for h := range result[d] {
+			if starts[h].IsZero() {
+				continue
+			}
+			// Each hour lasts until the next one that happens.
+			end := starts[h+1]
+			for next := h + 2; end.IsZero(); next++ {
+				end = starts[next]
+			}
+			hour := interval.Interval{Start: starts[h], End: end}
+			result[d][h] = float64(good.Intersect(interval.New(hour)).Duration()) / float64(hour.Duration())
+		}
+	}
+	return result
+}
+
+// hourStarts returns when each hour of the day begins, and the next midnight
+// last. Hours skipped by daylight saving time are zero.
+func hourStarts(day time.Time) [25]time.Time {
+	var starts [25]time.Time
+	y, m, dd := day.Date()
+	for h := range starts[:24] {
+		if t := time.Date(y, m, dd, h, 0, 0, 0, day.Location()); t.Hour() == h {
+			starts[h] = t
+		}
+	}
+	starts[24] = time.Date(y, m, dd+1, 0, 0, 0, 0, day.Location())
+	return starts
+}
+
+// Encode writes the heatmap as an SVG.
+func (img *Heatmap) Encode(w io.Writer) (int, error) {
+	if img.days <= 0 {
+		return 0, fmt.Errorf("cannot draw %d days", img.days)
+	}
+	var b bytes.Buffer
+	width := heatmapLabel + 24*heatmapCell
+	height := (img.days + 1) * heatmapCell
+	fontSize := heatmapCell * 3 / 4
+
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg" class="heatmap" role="img" aria-label="%s">`,
+		width, height, html.EscapeString(img.locale.T("Good times by hour for %d days from %s", img.days, img.locale.LongDate(img.start))))
+	fmt.Fprintf(&b, `<g class="labels" fill="#2b3238" font-size="%d" font-family="monospace">`, fontSize)
+	for h := 0; h < 24; h += 3 {
+		label := img.locale.Hour(time.Date(2000, 1, 1, h, 0, 0, 0, time.UTC))
+		fmt.Fprintf(&b, `<text class="hour_label" x="%d" y="%d">%s</text>`,
+			heatmapLabel+h*heatmapCell, fontSize, label)
+	}
+	for d := 0; d < img.days; d++ {
+		day := img.start.AddDate(0, 0, d)
+		fmt.Fprintf(&b, `<text class="day_label" x="0" y="%d">%s</text>`,
+			(d+1)*heatmapCell+fontSize, html.EscapeString(dayLabel(img.locale, day)))
+	}
+	io.WriteString(&b, `</g>`)
+
+	for d, row := range img.Coverage() {
+		day := img.start.AddDate(0, 0, d)
+		for h, cover := range row {
+			fmt.Fprintf(&b, `<rect class="heatmap_cell" fill="#2a9d8f" fill-opacity="%.2f" stroke="#2b3238" stroke-opacity="20%%" x="%d" y="%d" width="%d" height="%d">`,
+				cover,
+				heatmapLabel+h*heatmapCell, (d+1)*heatmapCell,
+				heatmapCell, heatmapCell)
+			fmt.Fprintf(&b, `<title>%s</title></rect>`, html.EscapeString(img.locale.T("%s at %s: %.0f minutes",
+				img.locale.LongDate(day),
+				img.locale.Time(time.Date(2000, 1, 1, h, 0, 0, 0, time.UTC)),
+				cover*60)))
+		}
+	}
+	io.WriteString(&b, `</svg>`)
+
+	return w.Write(b.Bytes())
+}
@@ -0,0 +1,228 @@
+package visualize
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// testPreds returns a high tide of 5ft and a low tide of -1ft every twelve
+// hours from start for the given number of days.
+func testPreds(start time.Time, days int) noaa.Predictions {
+	var preds noaa.Predictions
+	end := start.AddDate(0, 0, days)
+	for t, i := start, 0; !t.After(end); t, i = t.Add(6*time.Hour+12*time.Minute), i+1 {
+		p := noaa.Prediction{Time: noaa.Time(t), Height: -1, Type: noaa.LowTide}
+		if i%2 == 1 {
+			p.Height, p.Type = 5, noaa.HighTide
+		}
+		preds = append(preds, p)
+	}
+	return preds
+}
+
+// wellFormed fails the test unless svg is one complete SVG element.
+func wellFormed(t *testing.T, svg string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(svg))
+	depth, roots := 0, 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("malformed SVG: %v\n%s", err, svg)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+				if tok.Name.Local != "svg" {
+					t.Errorf("root element is %s, wanted svg", tok.Name.Local)
+				}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if depth != 0 || roots != 1 {
+		t.Errorf("SVG has %d roots and %d unclosed elements:\n%s", roots, depth, svg)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	start := time.Date(2021, time.June, 1, 9, 0, 0, 0, la)
+	days := 3
+	img := NewStrip(testPreds(start.AddDate(0, 0, -1), days+2), sunset.GetSunEvents(start.AddDate(0, 0, -1), time.Duration(days+2)*24*time.Hour, sunset.SantaCruz), start, days)
+	img.SetSize(600, 100)
+	img.SetGoodTimes([]meta.GoodTime{{Time: time.Date(2021, time.June, 2, 7, 0, 0, 0, la), Duration: 2 * time.Hour}})
+
+	var b bytes.Buffer
+	if _, err := img.Encode(&b); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	svg := b.String()
+	wellFormed(t, svg)
+	for _, tc := range []struct {
+		class string
+		want  int
+	}{
+		{`class="tide"`, 1},
+		{`class="day_label"`, days},
+		{`class="day_separator"`, days - 1},
+		{`class="good_window"`, 1},
+		// The nights before, between, and after the days.
+		{`class="night"`, days + 1},
+	} {
+		if got := strings.Count(svg, tc.class); got != tc.want {
+			t.Errorf("chart has %d of %s, wanted %d", got, tc.class, tc.want)
+		}
+	}
+	// The good time from 7AM to 9AM on the second day is a twelfth of a day.
+	if !strings.Contains(svg, `class="good_window" fill="#2a9d8f" fill-opacity="35%" x="258" y="0" width="17"`) {
+		t.Errorf("good time is not from 7AM to 9AM on the second day:\n%s", svg)
+	}
+}
+
+func TestStripInvalid(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location)
+	var b bytes.Buffer
+	if _, err := NewStrip(testPreds(start, 1), nil, start, 0).Encode(&b); err == nil {
+		t.Errorf("Encode of no days succeeded, wanted an error")
+	}
+	if b.Len() != 0 {
+		t.Errorf("Encode of no days wrote %q", b.String())
+	}
+}
+
+func TestHeatmapCoverage(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	for _, tc := range []struct {
+		name      string
+		start     time.Time
+		goodTimes []meta.GoodTime
+		// want is the coverage of the nonzero hours of the first day.
+		want map[int]float64
+	}{{
+		name:  "ordinary day",
+		start: time.Date(2021, time.June, 1, 0, 0, 0, 0, la),
+		goodTimes: []meta.GoodTime{
+			{Time: time.Date(2021, time.June, 1, 6, 0, 0, 0, la), Duration: 90 * time.Minute},
+			{Time: time.Date(2021, time.June, 1, 17, 45, 0, 0, la), Duration: 15 * time.Minute},
+		},
+		want: map[int]float64{6: 1, 7: 0.5, 17: 0.25},
+	}, {
+		// 2AM does not happen, so the good time covers 1AM and 3AM.
+		name:  "spring forward",
+		start: time.Date(2021, time.March, 14, 0, 0, 0, 0, la),
+		goodTimes: []meta.GoodTime{
+			{Time: time.Date(2021, time.March, 14, 1, 0, 0, 0, la), Duration: 2 * time.Hour},
+		},
+		want: map[int]float64{1: 1, 3: 1},
+	}, {
+		// 1AM happens twice, so the hour of 1AM is two hours long.
+		name:  "fall back",
+		start: time.Date(2021, time.November, 7, 0, 0, 0, 0, la),
+		goodTimes: []meta.GoodTime{
+			{Time: time.Date(2021, time.November, 7, 1, 0, 0, 0, la), Duration: time.Hour},
+		},
+		want: map[int]float64{1: 0.5},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			coverage := NewHeatmap(tc.goodTimes, tc.start, 2).Coverage()
+			if len(coverage) != 2 {
+				t.Fatalf("got coverage of %d days, wanted 2", len(coverage))
+			}
+			got := map[int]float64{}
+			for h, c := range coverage[0] {
+				if c != 0 {
+					got[h] = c
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("coverage of the first day (-want,+got): %s", diff)
+			}
+			if coverage[1] != [24]float64{} {
+				t.Errorf("second day has coverage %v, wanted none", coverage[1])
+			}
+		})
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location)
+	var b bytes.Buffer
+	if _, err := NewHeatmap(nil, start, 7).Encode(&b); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	svg := b.String()
+	wellFormed(t, svg)
+	if got, want := strings.Count(svg, `class="heatmap_cell"`), 7*24; got != want {
+		t.Errorf("heatmap has %d cells, wanted %d", got, want)
+	}
+	if got, want := strings.Count(svg, `class="day_label"`), 7; got != want {
+		t.Errorf("heatmap has %d day labels, wanted %d", got, want)
+	}
+
+	b.Reset()
+	if _, err := NewHeatmap(nil, start, -1).Encode(&b); err == nil {
+		t.Errorf("Encode of negative days succeeded, wanted an error")
+	}
+}
+
+func TestOverviewLocale(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location)
+	es := i18n.ForLanguage("es")
+
+	strip := NewStrip(testPreds(start.AddDate(0, 0, -1), 3), nil, start, 1)
+	strip.SetLocale(es)
+	heatmap := NewHeatmap(nil, start, 1)
+	heatmap.SetLocale(es)
+
+	for _, tc := range []struct {
+		name string
+		img  interface {
+			Encode(w io.Writer) (int, error)
+		}
+		want []string
+	}{{
+		name: "strip",
+		img:  strip,
+		want: []string{
+			`aria-label="Gráfico de mareas de 1 días desde el Martes, 1 de junio"`,
+			`<text class="day_label" x="8" y="24">Mar 01/06</text>`,
+		},
+	}, {
+		name: "heatmap",
+		img:  heatmap,
+		want: []string{
+			`aria-label="Buenos momentos por hora de 1 días desde el Martes, 1 de junio"`,
+			`>Mar 01/06</text>`,
+			`<text class="hour_label" x="400" y="15">15</text>`,
+			`<title>Martes, 1 de junio a las 15:00: 0 minutos</title>`,
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if _, err := tc.img.Encode(&b); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("chart does not contain %q:\n%s", want, b.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package visualize
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+// Colors of the raster image. They match the defaults of the SVG.
+var (
+	dayColor   = color.RGBA{0xff, 0xff, 0xe0, 0xff} // lightyellow
+	bandColor  = color.RGBA{0xf4, 0xa2, 0x61, 0xff}
+	tideColor  = color.RGBA{0x87, 0xce, 0xeb, 0xff}  // skyblue
+	goodColor  = color.NRGBA{0x2a, 0x9d, 0x8f, 0x59} // 35% opacity
+	nightColor = color.NRGBA{0x00, 0x00, 0xff, 0x40} // 25% opacity
+	axisColor  = color.NRGBA{0x2b, 0x32, 0x38, 0x80} // 50% opacity
+	backColor  = color.White
+)
+
+// Raster draws the image like Encode, without the labels.
+func (img *Tidal) Raster() (*image.RGBA, error) {
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	width, height := img.width, img.height
+	img.fit()
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(canvas, r, image.NewUniform(c), image.Point{}, draw.Over)
+	}
+	fill(canvas.Bounds(), backColor)
+
+	risex, setx, err := img.daylightX()
+	if err != nil {
+		return nil, err
+	}
+	fill(image.Rect(risex, 0, setx, height), dayColor)
+
+	if bandTop, bandBottom := img.bandY(); bandBottom > bandTop {
+		fill(image.Rect(0, bandTop, width, bandBottom), bandColor)
+	}
+
+	// Fill the tide below the curve, one column at a time.
+	spl := img.spline()
+	for x := 0; x < width; x++ {
+		h := spl.Eval(img.xToTime(x))
+		if math.IsNaN(h) {
+			continue
+		}
+		y := clamp(img.tideHeightToY(noaa.Height(h)), 0, height)
+		fill(image.Rect(x, y, x+1, height), tideColor)
+	}
+
+	for _, xs := range img.goodTimeXs() {
+		fill(image.Rect(xs[0], 0, xs[1], height), goodColor)
+	}
+
+	fill(image.Rect(0, 0, risex, height), nightColor)
+	fill(image.Rect(setx, 0, width, height), nightColor)
+
+	// Draw the hour ticks and height gridlines.
+	tickLen := height / 30
+	for hour := 1; hour < 24; hour++ {
+		x := img.timeToX(img.hour(hour))
+		length := tickLen
+		if hour%3 == 0 {
+			length *= 2
+		}
+		fill(image.Rect(x, height-length, x+1, height), axisColor)
+	}
+	for _, h := range img.gridlines() {
+		y := img.tideHeightToY(noaa.Height(h))
+		// Dash the line like the SVG.
+		for x := 0; x < width; x += 8 {
+			fill(image.Rect(x, y, x+4, y+1), axisColor)
+		}
+	}
+
+	return canvas, nil
+}
+
+// EncodePNG writes the image as a PNG.
+func (img *Tidal) EncodePNG(w io.Writer) error {
+	canvas, err := img.Raster()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, canvas)
+}
+
+func (img *Tidal) xToTime(x int) time.Time {
+	return img.date.Add(time.Duration(x) * img.dayLength() / time.Duration(img.width))
+}
@@ -0,0 +1,68 @@
+package visualize
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// over returns the color of layers drawn on top of each other.
+func over(layers ...color.Color) color.RGBA {
+	c := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	for _, l := range layers {
+		draw.Draw(c, c.Bounds(), image.NewUniform(l), image.Point{}, draw.Over)
+	}
+	return c.RGBAAt(0, 0)
+}
+
+func TestEncodePNG(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	date := time.Date(2021, time.June, 1, 0, 0, 0, 0, la)
+	img := testTidal(date)
+	img.SetSize(600, 150)
+	img.SetGoodTimes([]meta.GoodTime{{Time: date.Add(9 * time.Hour), Duration: time.Hour}})
+
+	var b bytes.Buffer
+	if err := img.EncodePNG(&b); err != nil {
+		t.Fatalf("EncodePNG failed: %v", err)
+	}
+	decoded, err := png.Decode(&b)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if got, want := decoded.Bounds(), image.Rect(0, 0, 600, 150); got != want {
+		t.Fatalf("got bounds %v, wanted %v", got, want)
+	}
+
+	// Each hour is 25 pixels wide. Sample between the ticks and dashes.
+	atHour := func(h float64) int { return int(h * 25) }
+	tideAt := date.Add(12*time.Hour + 24*time.Minute)
+	tideX := img.timeToX(tideAt)
+	tideY := img.tideHeightToY(noaa.Height(img.spline().Eval(tideAt)))
+	for _, tc := range []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"night", atHour(2), 0, over(backColor, nightColor)},
+		{"day", atHour(12), 0, over(backColor, dayColor)},
+		{"good time", atHour(9.5), 0, over(backColor, dayColor, goodColor)},
+		{"under the tide", tideX, tideY + 2, over(backColor, dayColor, tideColor)},
+		{"night under the tide", atHour(23.5), 149, over(backColor, tideColor, nightColor)},
+	} {
+		if got := color.RGBAModel.Convert(decoded.At(tc.x, tc.y)); got != tc.want {
+			t.Errorf("%s: pixel (%d, %d) is %v, wanted %v", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+	if got := color.RGBAModel.Convert(decoded.At(tideX, tideY-2)); got == over(backColor, dayColor, tideColor) {
+		t.Errorf("pixel (%d, %d) above the tide is the color of the tide", tideX, tideY-2)
+	}
+}
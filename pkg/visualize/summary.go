@@ -0,0 +1,100 @@
+package visualize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+)
+
+// SummaryRow is a single event of the day, for readers that cannot see the
+// chart.
+type SummaryRow struct {
+	Time time.Time
+	// End is the end of events that last a while, like good times.
+	End   time.Time
+	Event string
+	// Height of the tide, if the event is a tide.
+	Height string
+
+	locale i18n.Locale
+}
+
+// When describes the time of the event.
+func (r SummaryRow) When() string {
+	if r.End.IsZero() {
+		return r.locale.Time(r.Time)
+	}
+	return r.locale.T("%s until %s", r.locale.Time(r.Time), r.locale.Time(r.End))
+}
+
+// Summary lists the highs and lows of the tide, sunrise, sunset, and good
+// times of the day, in order.
+func (img *Tidal) Summary() []SummaryRow {
+	var rows []SummaryRow
+	l := img.locale
+	end := img.date.AddDate(0, 0, 1)
+	for _, p := range img.tidePreds.Between(img.date, end) {
+		event := l.T("High tide")
+		if p.Type == noaa.LowTide {
+			event = l.T("Low tide")
+		}
+		rows = append(rows, SummaryRow{
+			Time:   p.T(),
+			Event:  event,
+			Height: fmt.Sprintf("%.1fft", p.Height),
+			locale: l,
+		})
+	}
+
+	if rise, set, ok := img.sunEvents.Daylight(img.date); ok {
+		rows = append(rows,
+			SummaryRow{Time: rise, Event: l.T("Sunrise"), locale: l},
+			SummaryRow{Time: set, Event: l.T("Sunset"), locale: l})
+	}
+
+	for _, gt := range img.goodTimes {
+		gtEnd := gt.Time.Add(gt.Duration)
+		if !gt.Time.Before(end) || gtEnd.Before(img.date) {
+			continue
+		}
+		rows = append(rows, SummaryRow{
+			Time:   gt.Time,
+			End:    gtEnd,
+			Event:  l.T("Good time"),
+			locale: l,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Time.Before(rows[j].Time)
+	})
+	return rows
+}
+
+// title names the chart for assistive technology.
+func (img *Tidal) title() string {
+	return img.locale.T("Tide chart for %s", img.locale.LongDate(img.date))
+}
+
+// description describes the chart in sentences for assistive technology.
+func (img *Tidal) description() string {
+	var sentences []string
+	for _, row := range img.Summary() {
+		s := img.locale.T("%s at %s", row.Event, row.When())
+		if !row.End.IsZero() {
+			s = img.locale.T("%s from %s", row.Event, row.When())
+		}
+		if row.Height != "" {
+			s += ", " + row.Height
+		}
+		sentences = append(sentences, s+".")
+	}
+	if len(sentences) == 0 {
+		return img.locale.T("No tide data.")
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,162 @@
+package visualize
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+const (
+	// DefaultTerminalWidth and DefaultTerminalRows are the dimensions of a
+	// Terminal chart, in characters, unless set otherwise.
+	DefaultTerminalWidth = 80
+	DefaultTerminalRows  = 6
+
+	// gutter is the width of the height labels left of the chart.
+	gutter = 8
+)
+
+// blocks are the characters that fill an eighth of a row each, from empty to
+// full.
+var blocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// Terminal is a tide chart drawn with Unicode blocks for display in a
+// terminal.
+type Terminal struct {
+	spl        splines.Spline
+	sunEvents  sunset.SunEvents
+	goodTimes  []meta.GoodTime
+	start, end time.Time
+
+	width, rows int
+}
+
+// NewTerminal creates a chart of the tide described by spl from start to end.
+func NewTerminal(spl splines.Spline, sunEvents sunset.SunEvents, start, end time.Time) *Terminal {
+	return &Terminal{
+		spl:       spl,
+		sunEvents: sunEvents,
+		start:     start,
+		end:       end,
+		width:     DefaultTerminalWidth,
+		rows:      DefaultTerminalRows,
+	}
+}
+
+// SetSize sets the width of the chart, including its labels, and the number of
+// rows the tide takes up.
+func (img *Terminal) SetSize(width, rows int) {
+	img.width, img.rows = width, rows
+}
+
+// SetGoodTimes sets the good times to mark under the chart.
+func (img *Terminal) SetGoodTimes(goodTimes []meta.GoodTime) {
+	img.goodTimes = goodTimes
+}
+
+// Encode writes the chart as lines of text. Below the tide, it shades the
+// night and marks good times, and labels the start of each day.
+func (img *Terminal) Encode(w io.Writer) (int, error) {
+	cols := img.width - gutter
+	if cols <= 0 || img.rows <= 0 || !img.start.Before(img.end) {
+		return 0, fmt.Errorf("cannot draw a %dx%d chart from %v to %v", img.width, img.rows, img.start, img.end)
+	}
+
+	_, low := img.spl.Min(img.start, img.end)
+	_, high := img.spl.Max(img.start, img.end)
+	if math.IsNaN(low) || math.IsNaN(high) {
+		return 0, fmt.Errorf("no tide data from %v to %v", img.start, img.end)
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	// Each column is filled up to its height, in eighths of a row.
+	times := make([]time.Time, cols)
+	fill := make([]int, cols)
+	for c := range fill {
+		times[c] = img.start.Add(time.Duration(float64(img.end.Sub(img.start)) * (float64(c) + 0.5) / float64(cols)))
+		h := img.spl.Eval(times[c])
+		if math.IsNaN(h) {
+			continue
+		}
+		fill[c] = 1 + int(math.Round((h-low)/(high-low)*float64(img.rows*8-1)))
+	}
+
+	var b bytes.Buffer
+	for r := img.rows - 1; r >= 0; r-- {
+		switch r {
+		case img.rows - 1:
+			fmt.Fprintf(&b, "%6.1fft┤", high)
+		case 0:
+			fmt.Fprintf(&b, "%6.1fft┤", low)
+		default:
+			fmt.Fprintf(&b, "%8s│", "")
+		}
+		for _, f := range fill {
+			eighths := f - r*8
+			if eighths < 0 {
+				eighths = 0
+			} else if eighths > 8 {
+				eighths = 8
+			}
+			b.WriteRune(blocks[eighths])
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "%8s└%s\n", "", strings.Repeat("─", cols))
+	img.writeRow(&b, "night", times, func(t time.Time) rune {
+		if img.sunEvents.SunUp(t) {
+			return ' '
+		}
+		return '░'
+	})
+	img.writeRow(&b, "good", times, func(t time.Time) rune {
+		for _, gt := range img.goodTimes {
+			if !t.Before(gt.Time) && t.Before(gt.Time.Add(gt.Duration)) {
+				return '█'
+			}
+		}
+		return ' '
+	})
+	b.WriteString(img.dayLabels(times))
+
+	return w.Write(b.Bytes())
+}
+
+// writeRow writes a labeled row with the rune of each column.
+func (img *Terminal) writeRow(b *bytes.Buffer, label string, times []time.Time, at func(time.Time) rune) {
+	fmt.Fprintf(b, "%8s ", label)
+	for _, t := range times {
+		b.WriteRune(at(t))
+	}
+	b.WriteString("\n")
+}
+
+// dayLabels labels the first column of each day, as long as the labels do not
+// overlap.
+func (img *Terminal) dayLabels(times []time.Time) string {
+	line := []rune(strings.Repeat(" ", gutter+1+len(times)))
+	next := 0
+	for c, t := range times {
+		if c > 0 && t.YearDay() == times[c-1].YearDay() {
+			continue
+		}
+		label := []rune("^" + t.Format("Mon 1/2"))
+		at := gutter + 1 + c
+		if at < next || at+len(label) > len(line) {
+			continue
+		}
+		copy(line[at:], label)
+		next = at + len(label) + 1
+	}
+	return strings.TrimRight(string(line), " ") + "\n"
+}
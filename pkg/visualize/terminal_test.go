@@ -0,0 +1,76 @@
+package visualize
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+func TestTerminal(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, la)
+	end := start.AddDate(0, 0, 2)
+	spl := splines.Cubic.Interpolate(testPreds(start.Add(-time.Hour), 3))
+	img := NewTerminal(spl, sunset.GetSunEvents(start, 48*time.Hour, sunset.SantaCruz), start, end)
+	img.SetSize(8+48, 3)
+	// Each column is an hour.
+	img.SetGoodTimes([]meta.GoodTime{{Time: start.Add(6 * time.Hour), Duration: 2 * time.Hour}})
+
+	var b bytes.Buffer
+	if _, err := img.Encode(&b); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	// The tide, the axis, night, good times, and day labels.
+	if len(lines) != 3+4 {
+		t.Fatalf("got %d lines, wanted 7:\n%s", len(lines), b.String())
+	}
+	if !strings.ContainsRune(lines[0], '█') {
+		t.Errorf("top row never fills:\n%s", b.String())
+	}
+	if !strings.HasPrefix(lines[0], "   5.0ft┤") || !strings.HasPrefix(lines[2], "  -1.0ft┤") {
+		t.Errorf("height labels are not the range of the tide:\n%s", b.String())
+	}
+	for i, line := range lines[:5] {
+		if got := utf8.RuneCountInString(line); got != 8+1+48 {
+			t.Errorf("line %d is %d wide, wanted %d: %q", i, got, 8+1+48, line)
+		}
+	}
+	if want := "    good       ██"; !strings.HasPrefix(lines[5], want) || strings.Count(lines[5], "█") != 2 {
+		t.Errorf("good times are %q, wanted the hours of 6AM and 7AM", lines[5])
+	}
+	if want := "         ^Tue 6/1"; !strings.HasPrefix(lines[6], want) || !strings.Contains(lines[6], "^Wed 6/2") {
+		t.Errorf("day labels are %q, wanted both days", lines[6])
+	}
+}
+
+func TestTerminalInvalid(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location)
+	spl := splines.Cubic.Interpolate(testPreds(start, 1))
+	for _, tc := range []struct {
+		name  string
+		img   *Terminal
+		width int
+	}{
+		{"no room", NewTerminal(spl, nil, start, start.Add(time.Hour)), gutter},
+		{"backwards", NewTerminal(spl, nil, start.Add(time.Hour), start), DefaultTerminalWidth},
+		{"no tide", NewTerminal(nil, nil, start, start.Add(time.Hour)), DefaultTerminalWidth},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.img.SetSize(tc.width, DefaultTerminalRows)
+			var b bytes.Buffer
+			if _, err := tc.img.Encode(&b); err == nil {
+				t.Errorf("Encode succeeded, wanted an error")
+			}
+			if b.Len() != 0 {
+				t.Errorf("Encode wrote %q", b.String())
+			}
+		})
+	}
+}
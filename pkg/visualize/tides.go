@@ -1,11 +1,16 @@
 package visualize
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
+	"math"
 	"time"
 
+	"github.com/spencer-p/surfdash/pkg/i18n"
+	"github.com/spencer-p/surfdash/pkg/meta"
 	"github.com/spencer-p/surfdash/pkg/noaa"
 	"github.com/spencer-p/surfdash/pkg/noaa/splines"
 	"github.com/spencer-p/surfdash/pkg/sunset"
@@ -13,28 +18,162 @@ import (
 )
 
 const (
-	width  = 1200
-	height = 300
+	// DefaultWidth and DefaultHeight are the dimensions of a Tidal unless
+	// set otherwise.
+	DefaultWidth  = 1200
+	DefaultHeight = 300
+
+	// rangePadding is the room, in feet, left above and below the tide when
+	// the vertical range is fit to it.
+	rangePadding = 1
+	// maxGridlines is the most height gridlines to draw.
+	maxGridlines = 6
 )
 
 type Tidal struct {
 	date      time.Time
 	tidePreds noaa.Predictions
 	sunEvents sunset.SunEvents
+	opts      meta.Options
+	goodTimes []meta.GoodTime
+
+	// model interpolates modelPreds into the tide between predictions.
+	model      splines.Model
+	modelPreds noaa.Predictions
+
+	width, height int
+	// low and high are the tide heights at the bottom and top of the image.
+	// They are fit to the tide of each day unless fixed.
+	low, high float64
+	fixed     bool
+
+	// locale formats the labels and descriptions.
+	locale i18n.Locale
 }
 
 func NewTidal(tidePreds noaa.Predictions, sunEvents sunset.SunEvents) *Tidal {
-	return &Tidal{
-		tidePreds: tidePreds,
-		sunEvents: sunEvents,
+	img := &Tidal{
+		tidePreds:  tidePreds,
+		sunEvents:  sunEvents,
+		model:      splines.Cubic,
+		modelPreds: tidePreds,
+		width:      DefaultWidth,
+		height:     DefaultHeight,
+		locale:     i18n.English,
+	}
+	img.opts.ApplyDefaults()
+	return img
+}
+
+// SetSize sets the dimensions of the image.
+func (img *Tidal) SetSize(width, height int) {
+	img.width, img.height = width, height
+}
+
+// SetRange fixes the tide heights, in feet, at the bottom and top of the
+// image. By default the range is fit to the tide of the day.
+func (img *Tidal) SetRange(low, high float64) {
+	img.low, img.high = low, high
+	img.fixed = true
+}
+
+// SetLocale sets the locale of the labels and descriptions of the image.
+func (img *Tidal) SetLocale(l i18n.Locale) {
+	img.locale = l
+}
+
+// SetModel sets the model that interpolates the tide and the predictions it
+// expects. By default, the high and low tides are linked by splines.Cubic.
+func (img *Tidal) SetModel(model splines.Model, preds noaa.Predictions) {
+	img.model, img.modelPreds = model, preds
+}
+
+// spline returns the tide from the predictions around the day.
+func (img *Tidal) spline() splines.Spline {
+	first := img.modelPreds.IndexAtOrBefore(img.date)
+	if first < 0 {
+		first = 0
 	}
+	last := img.modelPreds.IndexAtOrBefore(img.date.AddDate(0, 0, 1)) + 1
+	if last >= len(img.modelPreds) {
+		last = len(img.modelPreds) - 1
+	}
+	if last < first {
+		return nil
+	}
+	return img.model.Interpolate(img.modelPreds[first : last+1])
 }
 
 func (img *Tidal) SetDate(t time.Time) {
 	img.date = timetricks.TrimClock(t)
 }
 
+// SetOptions sets the options whose band of tide heights is highlighted.
+func (img *Tidal) SetOptions(opts meta.Options) {
+	opts.ApplyDefaults()
+	img.opts = opts
+}
+
+// SetGoodTimes sets the good times to shade on the graph.
+func (img *Tidal) SetGoodTimes(goodTimes []meta.GoodTime) {
+	img.goodTimes = goodTimes
+}
+
+// Encode writes the chart as an SVG. If the chart cannot be drawn, it writes a
+// placeholder image instead and returns the error, so w always receives a
+// complete SVG.
 func (img *Tidal) Encode(w io.Writer) (int, error) {
+	var b bytes.Buffer
+	err := img.validate()
+	if err == nil {
+		_, err = img.encode(&b)
+	}
+	if err != nil {
+		b.Reset()
+		encodePlaceholder(&b, img.width, img.height)
+	}
+	n, werr := w.Write(b.Bytes())
+	if err == nil {
+		err = werr
+	}
+	return n, err
+}
+
+// validate checks that there is enough data to draw the chart.
+func (img *Tidal) validate() error {
+	if img.width <= 0 || img.height <= 0 {
+		return fmt.Errorf("cannot draw a %dx%d chart", img.width, img.height)
+	}
+	if img.fixed && img.high <= img.low {
+		return fmt.Errorf("cannot draw tide from %vft to %vft", img.low, img.high)
+	}
+	end := img.date.AddDate(0, 0, 1)
+	if len(img.tidePreds) == 0 ||
+		img.tidePreds[0].T().After(img.date) ||
+		img.tidePreds[len(img.tidePreds)-1].T().Before(end) {
+		return fmt.Errorf("tide predictions do not cover %s", img.date.Format("01/02"))
+	}
+	if _, _, ok := img.sunEvents.Daylight(img.date); !ok {
+		return fmt.Errorf("no sunrise and sunset for %s", img.date.Format("01/02"))
+	}
+	return nil
+}
+
+// encodePlaceholder writes an SVG that says there is no data.
+func encodePlaceholder(w io.Writer, width, height int) (int, error) {
+	if width <= 0 || height <= 0 {
+		width, height = DefaultWidth, DefaultHeight
+	}
+	return fmt.Fprintf(w, `<svg viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg" class="no_data" role="img" aria-label="No tide data">`+
+		`<rect class="no_data" fill="#e9ecee" x="0" y="0" width="%d" height="%d"/>`+
+		`<text x="%d" y="%d" text-anchor="middle" font-size="%d" font-family="monospace" fill="#2b3238">no data</text>`+
+		`</svg>`,
+		width, height,
+		width, height,
+		width/2, height/2, height/10)
+}
+
+func (img *Tidal) encode(w io.Writer) (int, error) {
 	var n int
 	var err error
 	io := func(nextn int, nexterr error) {
@@ -44,51 +183,47 @@ func (img *Tidal) Encode(w io.Writer) (int, error) {
 		}
 	}
 
-	io(fmt.Fprintf(w, `<svg viewBox="0 0 %d %d" onclick="" xmlns="http://www.w3.org/2000/svg">`, width, height))
+	width, height := img.width, img.height
+	img.fit()
+
+	id := img.date.Unix()
+	io(fmt.Fprintf(w, `<svg viewBox="0 0 %d %d" onclick="" xmlns="http://www.w3.org/2000/svg" data-low="%g" data-high="%g" data-seconds="%d" role="img" aria-labelledby="tide-title-%d tide-desc-%d">`,
+		width, height, img.low, img.high, int(img.dayLength().Seconds()), id, id))
+	io(fmt.Fprintf(w, `<title id="tide-title-%d">%s</title>`, id, html.EscapeString(img.title())))
+	io(fmt.Fprintf(w, `<desc id="tide-desc-%d">%s</desc>`, id, html.EscapeString(img.description())))
 
 	// Calculate dawn/dusk and draw the sunshine.
-	sunupIndex, ok := img.sunup(img.date)
-	if !ok || sunupIndex+1 > len(img.sunEvents) {
-		return n, fmt.Errorf("Not enough sun data")
-	}
-	sunup := img.sunEvents[sunupIndex]
-	sundown := img.sunEvents[sunupIndex+1]
-	risex := img.timeToX(sunup.Time)
-	setx := img.timeToX(sundown.Time)
+	risex, setx, err := img.daylightX()
+	if err != nil {
+		return n, err
+	}
 	io(fmt.Fprintf(w, `<rect class="daytime" fill="lightyellow" x="%d" y="%d" width="%d" height="%d"/>`,
 		risex, 0,
 		setx-risex, height))
 
-	// Draw markers for tide levels.
-	io(fmt.Fprintf(w, `<rect class="two_foot" fill="#e76f51" x="%d" y="%d" width="%d" height="%d"/>`,
-		0, tideHeightToY(2),
-		width, tideHeightToY(1)-tideHeightToY(2)+1))
-	io(fmt.Fprintf(w, `<rect class="one_foot" fill="#f4a261" x="%d" y="%d" width="%d" height="%d"/>`,
-		0, tideHeightToY(1),
-		width, tideHeightToY(0)-tideHeightToY(1)+1))
-	io(fmt.Fprintf(w, `<rect class="zero_foot" fill="#e9c46a" x="%d" y="%d" width="%d" height="%d"/>`,
-		0, tideHeightToY(0),
-		width, tideHeightToY(-2)-tideHeightToY(0)+1))
+	// Highlight the acceptable tide levels.
+	if bandTop, bandBottom := img.bandY(); bandBottom > bandTop {
+		io(fmt.Fprintf(w, `<rect class="tide_band" fill="#f4a261" x="%d" y="%d" width="%d" height="%d"/>`,
+			0, bandTop,
+			width, bandBottom-bandTop))
+	}
 
 	// Choose the first tide prediction to start from. Should be off screen; if
 	// not, just start at the beginning.
-	i, ok := img.indexPredPreceding(img.date)
-	if !ok {
+	i := img.tidePreds.IndexAtOrBefore(img.date)
+	if i < 0 {
 		i = 0
 	}
-	startPredI, endPredI := i, i
-
 	for ; i+1 < len(img.tidePreds); i += 1 {
 		x1 := img.timeToX(img.tidePreds[i].T())
-		y1 := tideHeightToY(img.tidePreds[i].Height)
+		y1 := img.tideHeightToY(img.tidePreds[i].Height)
 		if int(x1) > width {
 			break
 		}
-		endPredI = i + 1
 		io(fmt.Fprintf(w, `<path class="tide" fill="skyblue" d="M %d,%d `, x1, y1))
 
 		x2 := img.timeToX(img.tidePreds[i+1].T()) + 1 // +1 to create overlap
-		y2 := tideHeightToY(img.tidePreds[i+1].Height)
+		y2 := img.tideHeightToY(img.tidePreds[i+1].Height)
 
 		cx1, cy1 := (x1+x2)/2, y1
 		cx2, cy2 := cx1, y2
@@ -101,6 +236,14 @@ func (img *Tidal) Encode(w io.Writer) (int, error) {
 		io(fmt.Fprintf(w, `L %d,%d L %d,%d z"/>`, x2, height, x1, height))
 	}
 
+	// Shade the good times.
+	for _, xs := range img.goodTimeXs() {
+		x1, x2 := xs[0], xs[1]
+		io(fmt.Fprintf(w, `<rect class="good_window" fill="#2a9d8f" fill-opacity="35%%" x="%d" y="%d" width="%d" height="%d"/>`,
+			x1, 0,
+			x2-x1, height))
+	}
+
 	// Draw the night time shadows.
 	io(fmt.Fprintf(w, `<rect class="night" fill="blue" fill-opacity="25%%" x="%d" y="%d" width="%d" height="%d"/>`,
 		0, 0,
@@ -109,51 +252,162 @@ func (img *Tidal) Encode(w io.Writer) (int, error) {
 		setx, 0,
 		width-setx, height))
 
+	io(img.encodeAxes(w))
+
 	// Insert spline data as JSON.
-	splinePreds := img.tidePreds[startPredI : endPredI+1]
-	spline := splines.CurvesBetween(splinePreds)
-	io(fmt.Fprintf(w, `<text class="spline" visibility="hidden">`))
-	json.NewEncoder(w).Encode(spline)
+	io(fmt.Fprintf(w, `<text class="spline" visibility="hidden" aria-hidden="true">`))
+	json.NewEncoder(w).Encode(img.spline())
 	io(fmt.Fprintf(w, `</text>`))
 
 	// Insert date of this graph as unix.
-	io(fmt.Fprintf(w, `<text class="unixtime" visibility="hidden">%d</text>`, img.date.Unix()))
+	io(fmt.Fprintf(w, `<text class="unixtime" visibility="hidden" aria-hidden="true">%d</text>`, img.date.Unix()))
 
 	io(fmt.Fprintf(w, `</svg>`))
 
 	return n, err
 }
 
-func (img *Tidal) indexPredPreceding(t time.Time) (int, bool) {
-	left, right := 0, len(img.tidePreds)
-	for right-left > 1 {
-		mid := (left + right) / 2
-		midt := img.tidePreds[mid].T()
-		if midt.Before(t) {
-			left = mid
-		} else if midt.After(t) {
-			right = mid
-		} else if midt.Equal(t) {
-			return mid, true
+// fit fits the vertical range to the tide of the day, unless it is fixed.
+func (img *Tidal) fit() {
+	if !img.fixed {
+		img.low, img.high = img.fitRange()
+	}
+}
+
+// daylightX returns where the sun rises and sets on the image.
+func (img *Tidal) daylightX() (risex, setx int, err error) {
+	sunup, sundown, ok := img.sunEvents.Daylight(img.date)
+	if !ok {
+		return 0, 0, fmt.Errorf("Not enough sun data")
+	}
+	return img.timeToX(sunup), img.timeToX(sundown), nil
+}
+
+// bandY returns the top and bottom of the acceptable tide levels, clamped to
+// the image.
+func (img *Tidal) bandY() (top, bottom int) {
+	top = clamp(img.tideHeightToY(noaa.Height(*img.opts.HighTideThresh)), 0, img.height)
+	bottom = clamp(img.tideHeightToY(noaa.Height(*img.opts.LowTideThresh)), 0, img.height)
+	return top, bottom
+}
+
+// goodTimeXs returns the left and right of each good time that is on the
+// image.
+func (img *Tidal) goodTimeXs() [][2]int {
+	var result [][2]int
+	for _, gt := range img.goodTimes {
+		x1 := clamp(img.timeToX(gt.Time), 0, img.width)
+		x2 := clamp(img.timeToX(gt.Time.Add(gt.Duration)), 0, img.width)
+		if x2 > x1 {
+			result = append(result, [2]int{x1, x2})
 		}
 	}
-	ok := left < len(img.tidePreds)
-	return left, ok
+	return result
 }
 
-func (img *Tidal) sunup(t time.Time) (int, bool) {
-	for i := 0; i < len(img.sunEvents); i++ {
-		if img.sunEvents[i].Time.After(t) {
-			return i, true
+// fitRange returns whole feet above and below the tide during the day.
+func (img *Tidal) fitRange() (low, high float64) {
+	start := img.tidePreds.IndexAtOrBefore(img.date)
+	if start < 0 {
+		start = 0
+	}
+	end := img.tidePreds.Search(img.date.AddDate(0, 0, 1))
+	if end >= len(img.tidePreds) {
+		end = len(img.tidePreds) - 1
+	}
+	if start > end {
+		// No data; use the range of Santa Cruz.
+		return -2, 8
+	}
+
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, p := range img.tidePreds[start : end+1] {
+		low = math.Min(low, float64(p.Height))
+		high = math.Max(high, float64(p.Height))
+	}
+	return math.Floor(low) - rangePadding, math.Ceil(high) + rangePadding
+}
+
+// encodeAxes draws tick marks for each hour and labeled gridlines for tide
+// heights.
+func (img *Tidal) encodeAxes(w io.Writer) (int, error) {
+	var b bytes.Buffer
+	fontSize := img.height / 15
+	tickLen := img.height / 30
+
+	io.WriteString(&b, `<g class="axes" aria-hidden="true" stroke="#2b3238" stroke-opacity="50%">`)
+	for hour := 1; hour < 24; hour++ {
+		x := img.timeToX(img.hour(hour))
+		length := tickLen
+		if hour%3 == 0 {
+			length *= 2
 		}
+		fmt.Fprintf(&b, `<line class="tick" x1="%d" y1="%d" x2="%d" y2="%d"/>`,
+			x, img.height, x, img.height-length)
+	}
+
+	gridlines := img.gridlines()
+	for _, h := range gridlines {
+		y := img.tideHeightToY(noaa.Height(h))
+		fmt.Fprintf(&b, `<line class="gridline" stroke-dasharray="4" x1="%d" y1="%d" x2="%d" y2="%d"/>`,
+			0, y, img.width, y)
+	}
+	io.WriteString(&b, `</g>`)
+
+	fmt.Fprintf(&b, `<g class="labels" aria-hidden="true" fill="#2b3238" font-size="%d" font-family="monospace">`, fontSize)
+	for hour := 3; hour < 24; hour += 3 {
+		x := img.timeToX(img.hour(hour))
+		label := img.locale.Hour(img.hour(hour))
+		fmt.Fprintf(&b, `<text class="hour_label" text-anchor="middle" x="%d" y="%d">%s</text>`,
+			x, img.height-2*tickLen-fontSize/2, label)
+	}
+	for _, h := range gridlines {
+		y := img.tideHeightToY(noaa.Height(h))
+		fmt.Fprintf(&b, `<text class="height_label" x="%d" y="%d">%gft</text>`,
+			fontSize/2, y-fontSize/4, h)
 	}
-	return 0, false
+	io.WriteString(&b, `</g>`)
+
+	return w.Write(b.Bytes())
+}
+
+// gridlines returns the heights, in whole feet, between the bottom and top of
+// the image to draw gridlines at.
+func (img *Tidal) gridlines() []float64 {
+	step := math.Max(1, math.Ceil((img.high-img.low)/maxGridlines))
+	var result []float64
+	for h := math.Floor(img.low/step)*step + step; h < img.high; h += step {
+		result = append(result, h)
+	}
+	return result
+}
+
+func (img *Tidal) tideHeightToY(tideHeight noaa.Height) int {
+	scale := float64(img.height) / (img.high - img.low)
+	return img.height - int((float64(tideHeight)-img.low)*scale)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// dayLength is the length of the day of the image, which is not 24 hours
+// when the clocks change.
+func (img *Tidal) dayLength() time.Duration {
+	return img.date.AddDate(0, 0, 1).Sub(img.date)
 }
 
-func tideHeightToY(tideHeight noaa.Height) int {
-	return height - int((tideHeight+2)*(height/10)) // scaling ratio of img height to 10 feet of tide variance
+// hour returns the time on the clock at the hour of the day of the image.
+func (img *Tidal) hour(hour int) time.Time {
+	return timetricks.SetClock(img.date, time.Duration(hour), 0)
 }
 
 func (img *Tidal) timeToX(t time.Time) int {
-	return int(t.Unix()-img.date.Unix()) * width / (60 * 60 * 24)
+	return int(float64(t.Sub(img.date)) * float64(img.width) / float64(img.dayLength()))
 }
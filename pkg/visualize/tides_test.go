@@ -0,0 +1,222 @@
+package visualize
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spencer-p/surfdash/pkg/meta"
+	"github.com/spencer-p/surfdash/pkg/noaa"
+	"github.com/spencer-p/surfdash/pkg/noaa/splines"
+	"github.com/spencer-p/surfdash/pkg/sunset"
+)
+
+// testTidal returns a chart of the day of date with tides and sun from the
+// day before to the day after.
+func testTidal(date time.Time) *Tidal {
+	start := date.AddDate(0, 0, -1)
+	img := NewTidal(testPreds(start, 3), sunset.GetSunEvents(start, 3*24*time.Hour, sunset.SantaCruz))
+	img.SetDate(date)
+	return img
+}
+
+func TestTidalPlaceholder(t *testing.T) {
+	date := time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location)
+	for _, tc := range []struct {
+		name  string
+		setup func(img *Tidal)
+		// wantSize is the dimensions of the placeholder.
+		wantSize string
+	}{{
+		name:     "no sun data",
+		setup:    func(img *Tidal) { img.sunEvents = nil },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "predictions start after midnight",
+		setup:    func(img *Tidal) { img.tidePreds = testPreds(date.Add(12*time.Hour), 2) },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "predictions end before midnight",
+		setup:    func(img *Tidal) { img.tidePreds = testPreds(date.AddDate(0, 0, -1), 1) },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "no predictions",
+		setup:    func(img *Tidal) { img.tidePreds = nil },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "zero width",
+		setup:    func(img *Tidal) { img.SetSize(0, 300) },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "negative height",
+		setup:    func(img *Tidal) { img.SetSize(600, -1) },
+		wantSize: `viewBox="0 0 1200 300"`,
+	}, {
+		name:     "upside down range",
+		setup:    func(img *Tidal) { img.SetSize(600, 150); img.SetRange(5, -1) },
+		wantSize: `viewBox="0 0 600 150"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := testTidal(date)
+			tc.setup(img)
+
+			var b bytes.Buffer
+			n, err := img.Encode(&b)
+			if err == nil {
+				t.Errorf("Encode succeeded, wanted an error")
+			}
+			if n != b.Len() {
+				t.Errorf("Encode wrote %d bytes but returned %d", b.Len(), n)
+			}
+			svg := b.String()
+			wellFormed(t, svg)
+			if !strings.Contains(svg, `class="no_data"`) {
+				t.Errorf("chart is not a placeholder:\n%s", svg)
+			}
+			if !strings.Contains(svg, tc.wantSize) {
+				t.Errorf("placeholder does not have %s:\n%s", tc.wantSize, svg)
+			}
+			if _, err := img.Raster(); err == nil {
+				t.Errorf("Raster succeeded, wanted an error")
+			}
+		})
+	}
+}
+
+func TestTidalWellFormed(t *testing.T) {
+	img := testTidal(time.Date(2021, time.June, 1, 0, 0, 0, 0, sunset.SantaCruz.Location))
+	var b bytes.Buffer
+	if _, err := img.Encode(&b); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	wellFormed(t, b.String())
+	if strings.Contains(b.String(), "no_data") {
+		t.Errorf("chart is a placeholder")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	date := time.Date(2021, time.June, 1, 0, 0, 0, 0, la)
+	at := func(h, m int) time.Time { return time.Date(2021, time.June, 1, h, m, 0, 0, la) }
+	var preds noaa.Predictions
+	for i, t := range []time.Time{
+		at(-2, 0), at(4, 0), at(10, 0), at(16, 0), at(22, 0), at(28, 0),
+	} {
+		p := noaa.Prediction{Time: noaa.Time(t), Height: 5, Type: noaa.HighTide}
+		if i%2 == 1 {
+			p.Height, p.Type = -1, noaa.LowTide
+		}
+		preds = append(preds, p)
+	}
+	img := NewTidal(preds, sunset.GetSunEvents(date, 24*time.Hour, sunset.SantaCruz))
+	img.SetDate(date)
+	img.SetGoodTimes([]meta.GoodTime{
+		{Time: at(6, 30), Duration: 90 * time.Minute},
+		// Good times of other days are left out.
+		{Time: at(30, 0), Duration: time.Hour},
+	})
+
+	type row struct{ When, Event, Height string }
+	var got []row
+	for _, r := range img.Summary() {
+		got = append(got, row{r.When(), r.Event, r.Height})
+	}
+	want := []row{
+		{"4:00 AM", "Low tide", "-1.0ft"},
+		{"5:51 AM", "Sunrise", ""},
+		{"6:30 AM until 8:00 AM", "Good time", ""},
+		{"10:00 AM", "High tide", "5.0ft"},
+		{"4:00 PM", "Low tide", "-1.0ft"},
+		{"8:23 PM", "Sunset", ""},
+		{"10:00 PM", "High tide", "5.0ft"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("summary (-want,+got): %s", diff)
+	}
+}
+
+func TestTidalDaylightSaving(t *testing.T) {
+	la := sunset.SantaCruz.Location
+	for _, tc := range []struct {
+		name    string
+		date    time.Time
+		seconds int
+		// noonX is where noon on the clock falls on a chart 2400 wide.
+		noonX int
+	}{
+		{"ordinary day", time.Date(2021, time.June, 1, 0, 0, 0, 0, la), 24 * 60 * 60, 1200},
+		{"spring forward", time.Date(2021, time.March, 14, 0, 0, 0, 0, la), 23 * 60 * 60, 2400 * 11 / 23},
+		{"fall back", time.Date(2021, time.November, 7, 0, 0, 0, 0, la), 25 * 60 * 60, 2400 * 13 / 25},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := testTidal(tc.date.Add(8 * time.Hour))
+			img.SetSize(2400, 300)
+
+			if got := img.timeToX(img.hour(12)); got != tc.noonX {
+				t.Errorf("noon is at x=%d, wanted %d", got, tc.noonX)
+			}
+			if got := img.timeToX(tc.date.AddDate(0, 0, 1)); got != 2400 {
+				t.Errorf("the next midnight is at x=%d, wanted the right edge", got)
+			}
+			if got, want := img.xToTime(2400), tc.date.AddDate(0, 0, 1); !got.Equal(want) {
+				t.Errorf("the right edge is at %v, wanted %v", got, want)
+			}
+
+			var b bytes.Buffer
+			if _, err := img.Encode(&b); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			svg := b.String()
+			if want := fmt.Sprintf(`data-seconds="%d"`, tc.seconds); !strings.Contains(svg, want) {
+				t.Errorf("chart does not contain %s", want)
+			}
+			// Every label from 3AM to 9PM is drawn once, in order.
+			var labels []string
+			for _, m := range regexp.MustCompile(`class="hour_label"[^>]*>([^<]*)<`).FindAllStringSubmatch(svg, -1) {
+				labels = append(labels, m[1])
+			}
+			want := []string{"3AM", "6AM", "9AM", "12PM", "3PM", "6PM", "9PM"}
+			if diff := cmp.Diff(want, labels); diff != "" {
+				t.Errorf("hour labels (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestTidalModel(t *testing.T) {
+	date := time.Date(2021, time.June, 1, 8, 0, 0, 0, sunset.SantaCruz.Location)
+	for _, tc := range []struct {
+		name  string
+		model splines.Model
+		want  string
+	}{
+		{"default", nil, `"type":"cubic"`},
+		{"cosine", splines.Cosine, `"type":"cosine"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := testTidal(date)
+			if tc.model != nil {
+				img.SetModel(tc.model, testPreds(date.AddDate(0, 0, -1), 3))
+			}
+			var b bytes.Buffer
+			if _, err := img.Encode(&b); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			m := regexp.MustCompile(`class="spline"[^>]*>([^<]*)<`).FindStringSubmatch(b.String())
+			if m == nil {
+				t.Fatalf("chart has no spline")
+			}
+			if !strings.Contains(m[1], tc.want) {
+				t.Errorf("spline %s does not contain %s", m[1], tc.want)
+			}
+			if _, err := img.Raster(); err != nil {
+				t.Errorf("Raster failed: %v", err)
+			}
+		})
+	}
+}
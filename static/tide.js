@@ -1,12 +1,17 @@
 var dateFormatter = new Intl.DateTimeFormat('en-US', { hour: "numeric", minute: "numeric" });
 
-for (hoverEl of document.querySelectorAll(".goodtime_glance")) {
-	// iOS touchmove doesn't work unless touchstart is also set..
-	hoverEl.addEventListener("touchstart", touchMove, {capture: true, passive: true});
+// The spline encoding this file understands. See pkg/noaa/splines/encoding.go.
+const splineVersion = 1;
 
-	// Handlers for touch or mouse.
-	hoverEl.addEventListener("mousemove", svgMove, {capture: true, passive: true});
-	hoverEl.addEventListener("touchmove", touchMove, {capture: true, passive: true});
+if (typeof document !== "undefined") {
+	for (hoverEl of document.querySelectorAll(".goodtime_glance")) {
+		// iOS touchmove doesn't work unless touchstart is also set..
+		hoverEl.addEventListener("touchstart", touchMove, {capture: true, passive: true});
+
+		// Handlers for touch or mouse.
+		hoverEl.addEventListener("mousemove", svgMove, {capture: true, passive: true});
+		hoverEl.addEventListener("touchmove", touchMove, {capture: true, passive: true});
+	}
 }
 
 
@@ -32,6 +37,12 @@ function findSVG(el) {
 }
 
 function updateGraph(svg, x, y) {
+	let splineEl = svg.querySelector(".spline");
+	if (!splineEl) {
+		// Placeholder images have no tide to show.
+		return;
+	}
+
 	// The cursor point, translated into svg coordinates
 	var pt = svg.createSVGPoint();
 	pt.x = x;
@@ -41,7 +52,7 @@ function updateGraph(svg, x, y) {
 	y = cursorpt.y;
 
 
-	let spline = JSON.parse(svg.querySelector(".spline").innerHTML);
+	let spline = decodeSpline(splineEl.innerHTML);
 	let date = Number(svg.querySelector(".unixtime").innerHTML);
 	let abs_t = xToTime(svg, date, x)
 	let tideHeight = evalSpline(spline, date, abs_t); 
@@ -74,6 +85,19 @@ function getdot(svg) {
 	return dot;
 }
 
+// decodeSpline parses the JSON encoding of a spline into a list of curves.
+function decodeSpline(text) {
+	let decoded = JSON.parse(text);
+	if (Array.isArray(decoded)) {
+		// Unversioned splines are a bare list of cubic curves.
+		return decoded;
+	}
+	if (decoded.version !== splineVersion || decoded.units !== "ft" || decoded.time_base !== "unix") {
+		throw new Error("unsupported spline encoding: version " + decoded.version);
+	}
+	return decoded.curves;
+}
+
 function evalSpline(spline, date, abs_t) {
 	let n = spline.length;
 	if (n === 0) {
@@ -91,7 +115,14 @@ function evalSpline(spline, date, abs_t) {
 
 function evalCurve(curve, abs_t) {
 	let x = xrel(curve.start, abs_t);
-	return curve.a*Math.pow(x,3) + curve.b*Math.pow(x, 2) + curve.c*x + curve.d;
+	switch (curve.type || "cubic") {
+	case "cubic":
+		return curve.a*Math.pow(x,3) + curve.b*Math.pow(x, 2) + curve.c*x + curve.d;
+	case "cosine":
+		return curve.mid + curve.amp*Math.cos(Math.PI*x/(curve.end - curve.start));
+	default:
+		return NaN;
+	}
 }
 
 function xrel(origin, abs_t) {
@@ -100,14 +131,18 @@ function xrel(origin, abs_t) {
 
 function xToTime(svg, date, x) {
 	const width = svg.viewBox.baseVal.width;
-	let t = (x/width)*(24*60*60);
+	// Days are not 24 hours long when the clocks change.
+	const seconds = Number(svg.getAttribute("data-seconds")) || 24*60*60;
+	let t = (x/width)*seconds;
 	let abs_t = date+t;
 	return abs_t;
 }
 
 function heightToY(svg, tideHeight) {
 	const height = svg.viewBox.baseVal.height;
-	return height - Math.floor((tideHeight+2)*(height/10))
+	const low = Number(svg.dataset.low);
+	const high = Number(svg.dataset.high);
+	return height - Math.floor((tideHeight-low)*(height/(high-low)));
 }
 
 function svgTideY(svg, x) {
@@ -302,3 +337,8 @@ let utils = {
 		return points.map(d);
 	},
 }
+
+if (typeof module !== "undefined") {
+	// Allow the evaluation to be checked against the server from node.
+	module.exports = { decodeSpline, evalSpline };
+}